@@ -4,15 +4,58 @@ import (
 	"fmt"
 	"net/http"
 	"ttv-statistics/handlers"
+	"ttv-statistics/openapi"
 )
 
 const (
 	apiName            = "ttv-statistics"
 	getVideoStatistics = "getstreamervideostatistics"
+	getClipStatistics  = "getstreamerclipstatistics"
+	compareStreamers   = "compare"
+	liveStatus         = "live"
+	history            = "history"
+	health             = "health"
+	openAPIDocument    = "openapi.json"
 )
 
-var (
-	EndpointMapping = map[string]func(w http.ResponseWriter, r *http.Request){
-		fmt.Sprintf("/%s/%s/{%s}", apiName, getVideoStatistics, handlers.UserNamePathParam): handlers.GetStreamerVideoStatistics,
+// Endpoint pairs a handler with the OpenAPI operation describing it, so the served document cannot
+// drift from the registered routes.
+type Endpoint struct {
+	Handler   http.HandlerFunc
+	Operation openapi.Operation
+}
+
+func EndpointMapping(h *handlers.Handlers) map[string]Endpoint {
+	return map[string]Endpoint{
+		fmt.Sprintf("/%s/%s/{%s}", apiName, getVideoStatistics, handlers.UserNamePathParam): {
+			Handler:   h.GetStreamerVideoStatistics,
+			Operation: handlers.GetStreamerVideoStatisticsOperation(),
+		},
+		fmt.Sprintf("/%s/%s/{%s}", apiName, getClipStatistics, handlers.UserNamePathParam): {
+			Handler:   h.GetStreamerClipStatistics,
+			Operation: handlers.GetStreamerClipStatisticsOperation(),
+		},
+		fmt.Sprintf("/%s/%s", apiName, compareStreamers): {
+			Handler:   h.CompareStreamers,
+			Operation: handlers.CompareStreamersOperation(),
+		},
+		fmt.Sprintf("/%s/%s/{%s}", apiName, liveStatus, handlers.UserNamePathParam): {
+			Handler:   h.GetLiveStatus,
+			Operation: handlers.GetLiveStatusOperation(),
+		},
+		fmt.Sprintf("/%s/%s/{%s}", apiName, history, handlers.UserNamePathParam): {
+			Handler:   h.GetStreamerHistory,
+			Operation: handlers.GetStreamerHistoryOperation(),
+		},
+		fmt.Sprintf("/%s/%s", apiName, health): {
+			Handler:   h.Health,
+			Operation: handlers.HealthOperation(),
+		},
+		fmt.Sprintf("/%s/%s", apiName, openAPIDocument): {
+			Handler: handlers.OpenAPIDocument(func() openapi.Document {
+				return NewOpenAPIDocument(EndpointMapping(h))
+			}),
+			Operation: handlers.OpenAPIDocumentOperation(),
+		},
 	}
-)
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"ttv-statistics/openapi"
+)
+
+const (
+	openAPIVersion = "1.0.0"
+)
+
+// NewOpenAPIDocument describes endpoints, keyed by their http.ServeMux patterns, which use the same
+// {name} syntax for path parameters as OpenAPI paths.
+func NewOpenAPIDocument(endpoints map[string]Endpoint) openapi.Document {
+
+	document := openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       apiName,
+			Description: "Fetches and aggregates Twitch streamer video statistics via the Helix API",
+			Version:     openAPIVersion,
+		},
+		Paths: map[string]*openapi.PathItem{},
+	}
+
+	for pattern, endpoint := range endpoints {
+		operation := endpoint.Operation
+		document.Paths[pattern] = &openapi.PathItem{Get: &operation}
+	}
+
+	return document
+}
@@ -0,0 +1,144 @@
+package api_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"ttv-statistics/api"
+	"ttv-statistics/handlers"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/openapi"
+)
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// TestEndpointOperations checks every registered pattern is a valid OpenAPI path whose path parameters
+// are all declared by its operation.
+func TestEndpointOperations(t *testing.T) {
+
+	operationIDs := []string{}
+
+	for pattern, endpoint := range api.EndpointMapping(handlers.NewHandlers(helixclient.NewClient())) {
+
+		if !strings.HasPrefix(pattern, "/") || strings.Contains(pattern, "...") {
+			t.Errorf("pattern %q is not an OpenAPI path", pattern)
+		}
+
+		if endpoint.Operation.OperationID == "" || len(endpoint.Operation.Responses) == 0 {
+			t.Errorf("pattern %q has an incomplete operation", pattern)
+		}
+		operationIDs = append(operationIDs, endpoint.Operation.OperationID)
+
+		for _, match := range pathParamPattern.FindAllStringSubmatch(pattern, -1) {
+			declared := slices.ContainsFunc(endpoint.Operation.Parameters, func(p openapi.Parameter) bool {
+				return p.Name == match[1] && p.In == openapi.InPath && p.Required
+			})
+			if !declared {
+				t.Errorf("pattern %q does not declare its path parameter %q", pattern, match[1])
+			}
+		}
+	}
+
+	slices.Sort(operationIDs)
+	if len(slices.Compact(operationIDs)) != len(operationIDs) {
+		t.Errorf("operation ids are not unique: %v", operationIDs)
+	}
+}
+
+func TestServeOpenAPIDocument(t *testing.T) {
+
+	h := handlers.NewHandlers(helixclient.NewClient())
+	server := httptest.NewServer(api.WiredMux(h))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/ttv-statistics/openapi.json")
+	if err != nil {
+		t.Fatalf("failed to get openapi document: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var document openapi.Document
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		t.Fatalf("failed to decode openapi document: %v", err)
+	}
+
+	if document.OpenAPI != openapi.Version {
+		t.Errorf("expected openapi version %s, got %s", openapi.Version, document.OpenAPI)
+	}
+
+	for pattern := range api.EndpointMapping(h) {
+		if item, ok := document.Paths[pattern]; !ok || item.Get == nil {
+			t.Errorf("document does not describe %s", pattern)
+		}
+	}
+	if len(document.Paths) != len(api.EndpointMapping(h)) {
+		t.Errorf("expected %d paths, got %d", len(api.EndpointMapping(h)), len(document.Paths))
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+
+	type testCase struct {
+		name         string
+		target       string
+		expectedCode handlers.ErrorCode
+		expectedBody string
+	}
+
+	testCases := []testCase{
+		{
+			name:         "Missing required parameter",
+			target:       "/ttv-statistics/compare?N=3",
+			expectedCode: handlers.ErrorCodeMissingParameter,
+			expectedBody: "missing required URL param users",
+		},
+		{
+			name:         "Value outside the enum",
+			target:       "/ttv-statistics/getstreamervideostatistics/good_user?N=3&duration_format=hours",
+			expectedCode: handlers.ErrorCodeInvalidParameter,
+			expectedBody: "duration_format must be one of ns, seconds, iso8601, go",
+		},
+		{
+			name:         "Precision out of range",
+			target:       "/ttv-statistics/getstreamervideostatistics/good_user?N=3&precision=11",
+			expectedCode: handlers.ErrorCodeInvalidParameter,
+			expectedBody: "precision must be an integer between 0 and 10",
+		},
+	}
+
+	server := httptest.NewServer(api.WiredMux(handlers.NewHandlers(helixclient.NewClient())))
+	defer server.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			resp, err := http.Get(server.URL + tc.target)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			bodyBytes, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+			}
+
+			var problem handlers.Problem
+			if err := json.Unmarshal(bodyBytes, &problem); err != nil {
+				t.Fatalf("failed to decode problem: %v", err)
+			}
+			if problem.Code != tc.expectedCode || problem.Detail != tc.expectedBody {
+				t.Errorf("expected %s %q, got %s %q", tc.expectedCode, tc.expectedBody, problem.Code, problem.Detail)
+			}
+		})
+	}
+}
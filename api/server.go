@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"ttv-statistics/handlers"
 	"ttv-statistics/helixclient"
+	"ttv-statistics/storage"
 )
 
 var (
@@ -13,7 +15,9 @@ var (
 )
 
 type ttvStatisticsServer struct {
-	server http.Server
+	server    http.Server
+	helixHost string
+	authHost  string
 }
 
 func (s *ttvStatisticsServer) Run() {
@@ -22,7 +26,8 @@ func (s *ttvStatisticsServer) Run() {
 		log.Println("\n\t",
 			fmt.Sprintf("Serving %s\n\t", apiName),
 			fmt.Sprintf("host=%s\n\t", Host),
-			fmt.Sprintf("helix-host=%s\n", helixclient.HelixHost),
+			fmt.Sprintf("helix-host=%s\n\t", s.helixHost),
+			fmt.Sprintf("auth-host=%s\n", s.authHost),
 		)
 		err := s.server.ListenAndServe()
 		if err != nil && err != http.ErrServerClosed {
@@ -42,22 +47,29 @@ func (s *ttvStatisticsServer) ShutDownServer(ctx context.Context) error {
 	return nil
 }
 
-func NewTTVStatisticsServer() *ttvStatisticsServer {
+// NewTTVStatisticsServer serves the API using helix, which may wrap the underlying client, so the hosts
+// it talks to are passed separately for logging. History is read from store.
+func NewTTVStatisticsServer(helix helixclient.API, store storage.Repository, helixHost, authHost string) *ttvStatisticsServer {
 
 	return &ttvStatisticsServer{
 		server: http.Server{
 			Addr:    Host,
-			Handler: wiredMux(),
+			Handler: wiredMux(handlers.NewHandlers(helix, handlers.WithRepository(store))),
 		},
+		helixHost: helixHost,
+		authHost:  authHost,
 	}
 }
 
-func wiredMux() *http.ServeMux {
+func wiredMux(h *handlers.Handlers) *http.ServeMux {
 	mux := http.NewServeMux()
 
-	for endpoint, handler := range EndpointMapping {
-		mux.HandleFunc(endpoint, handler)
+	for pattern, endpoint := range EndpointMapping(h) {
+		mux.HandleFunc(pattern, handlers.ValidateParameters(endpoint.Operation, endpoint.Handler))
 	}
 
+	// unmatched paths fall through to the root pattern, so they are reported as problems too
+	mux.HandleFunc("/", h.NotFound)
+
 	return mux
 }
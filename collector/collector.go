@@ -0,0 +1,265 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/statstools"
+	"ttv-statistics/storage"
+)
+
+const (
+	DefaultInterval    time.Duration = time.Hour
+	DefaultVideoCount  int           = 20
+	DefaultConcurrency int           = 4
+	DefaultJitter      float64       = 0.1
+)
+
+// Collector periodically snapshots the statistics of a watchlist of streamers. Rounds are spaced by
+// Interval, randomised by Jitter so several instances do not reach helix together, and at most
+// Concurrency streamers are fetched at once so the collector leaves rate limit for the API.
+type Collector struct {
+	helix     helixclient.API
+	store     storage.Repository
+	watchlist []string
+
+	interval    time.Duration
+	videoCount  int
+	concurrency int
+	jitter      float64
+	filter      helixclient.VideoFilter
+	now         func() time.Time
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+type Option func(*Collector)
+
+func WithInterval(interval time.Duration) Option {
+	return func(c *Collector) {
+		c.interval = interval
+	}
+}
+
+// WithVideoCount sets the number of most recent videos each snapshot is aggregated over.
+func WithVideoCount(n int) Option {
+	return func(c *Collector) {
+		c.videoCount = n
+	}
+}
+
+func WithConcurrency(concurrency int) Option {
+	return func(c *Collector) {
+		c.concurrency = concurrency
+	}
+}
+
+// WithJitter sets the fraction, between 0 and 1, of the interval that is randomised.
+func WithJitter(jitter float64) Option {
+	return func(c *Collector) {
+		c.jitter = jitter
+	}
+}
+
+func WithVideoFilter(filter helixclient.VideoFilter) Option {
+	return func(c *Collector) {
+		c.filter = filter
+	}
+}
+
+func WithClock(now func() time.Time) Option {
+	return func(c *Collector) {
+		c.now = now
+	}
+}
+
+// NewCollector returns a collector recording the streamers on watchlist, their videos and snapshots
+// of their statistics in store.
+func NewCollector(helix helixclient.API, store storage.Repository, watchlist []string, opts ...Option) *Collector {
+
+	c := &Collector{
+		helix:       helix,
+		store:       store,
+		watchlist:   watchlist,
+		interval:    DefaultInterval,
+		videoCount:  DefaultVideoCount,
+		concurrency: DefaultConcurrency,
+		jitter:      DefaultJitter,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Start runs collection rounds in the background until Stop is called or ctx is done. The first
+// round starts after a random fraction of the jittered interval, spreading instances started together.
+func (c *Collector) Start(ctx context.Context) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel != nil {
+		return
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.stopped = make(chan struct{})
+
+	go c.run(ctx)
+}
+
+// Stop cancels the round in progress, if any, and waits for the collector to exit or ctx to be done.
+func (c *Collector) Stop(ctx context.Context) error {
+
+	c.mu.Lock()
+	cancel, stopped := c.cancel, c.stopped
+	c.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("message=%s error=%w", "snapshot collector did not stop", ctx.Err())
+	}
+}
+
+func (c *Collector) run(ctx context.Context) {
+
+	defer close(c.stopped)
+
+	timer := time.NewTimer(c.firstDelay())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if err := c.CollectOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("message=%s error=%v", "snapshot collection round failed", err)
+		}
+
+		timer.Reset(c.nextDelay())
+	}
+}
+
+func (c *Collector) firstDelay() time.Duration {
+	return time.Duration(rand.Float64() * c.clampedJitter() * float64(c.interval))
+}
+
+// nextDelay returns the interval shifted by up to half the jittered fraction either way
+func (c *Collector) nextDelay() time.Duration {
+
+	offset := (rand.Float64() - 0.5) * c.clampedJitter() * float64(c.interval)
+
+	return max(c.interval+time.Duration(offset), time.Millisecond)
+}
+
+func (c *Collector) clampedJitter() float64 {
+	return min(max(c.jitter, 0), 1)
+}
+
+// CollectOnce snapshots every streamer on the watchlist. A streamer that cannot be snapshotted does not
+// stop the others; their errors are joined into the returned error.
+func (c *Collector) CollectOnce(ctx context.Context) error {
+
+	lookups, err := c.helix.LookupUsers(ctx, c.watchlist, nil)
+	if err != nil {
+		return fmt.Errorf("message=%s error=%w", "failed to look up watchlist", err)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		errs      []error
+		semaphore = make(chan struct{}, max(c.concurrency, 1))
+	)
+
+	for login, lookup := range lookups.ByLogin {
+
+		if !lookup.Found {
+			log.Printf("message=%s login=%s", "watchlist streamer not found", login)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				return
+			}
+
+			if err := c.snapshot(ctx, lookup.UserInfo); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("message=%s error=%w", "snapshot collection cancelled", err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Collector) snapshot(ctx context.Context, user helixclient.UserInfo) error {
+
+	if err := c.store.SaveStreamer(ctx, user); err != nil {
+		return fmt.Errorf("message=%s login=%s error=%w", "failed to save streamer", user.Login, err)
+	}
+
+	videosData, err := c.helix.GetStreamerFirstNVideoStatistics(ctx, user.ID, c.videoCount, c.filter)
+	if err != nil {
+		return fmt.Errorf("message=%s login=%s error=%w", "failed to obtain videos", user.Login, err)
+	}
+
+	if err := c.store.SaveVideos(ctx, user.ID, videosData.Data); err != nil {
+		return fmt.Errorf("message=%s login=%s error=%w", "failed to save videos", user.Login, err)
+	}
+
+	statistics, err := statstools.AggregateStreamerVideoStatistics(videosData.Data)
+	if err != nil {
+		return fmt.Errorf("message=%s login=%s error=%w", "failed to aggregate video statistics", user.Login, err)
+	}
+	statistics.Filters = c.filter.WithDefaults()
+
+	snapshot := statstools.Snapshot{
+		Login:       user.Login,
+		UserID:      user.ID,
+		CollectedAt: c.now().UTC(),
+		Statistics:  statistics,
+	}
+
+	if err := c.store.SaveSnapshot(ctx, snapshot); err != nil {
+		return fmt.Errorf("message=%s login=%s error=%w", "failed to save snapshot", user.Login, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package collector_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"ttv-statistics/collector"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/statstools"
+	"ttv-statistics/storage"
+	"ttv-statistics/testutil"
+)
+
+var collectedAt = time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+func savedSnapshots(t *testing.T, store storage.Repository, login string) []statstools.Snapshot {
+
+	snapshots, err := store.Snapshots(context.Background(), login, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return snapshots
+}
+
+func TestCollectOnce(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name              string
+		watchlist         []string
+		videoCount        int
+		expectedSnapshots map[string]statstools.LastNVideoStatistics
+		expectError       bool
+	}
+
+	testCases := []testCase{
+		{
+			name:       "Snapshots every streamer on the watchlist",
+			watchlist:  []string{"good_user", testutil.SecondUserName, testutil.NoVideosUserName},
+			videoCount: 20,
+			expectedSnapshots: map[string]statstools.LastNVideoStatistics{
+				"good_user":               {VideoCount: 3, ViewCountSum: 300},
+				testutil.SecondUserName:   {VideoCount: 2, ViewCountSum: 900},
+				testutil.NoVideosUserName: {},
+			},
+		},
+		{
+			name:       "Aggregates the configured number of videos",
+			watchlist:  []string{"good_user"},
+			videoCount: 2,
+			expectedSnapshots: map[string]statstools.LastNVideoStatistics{
+				"good_user": {VideoCount: 2, ViewCountSum: 250},
+			},
+		},
+		{
+			name:       "Unknown streamers are skipped",
+			watchlist:  []string{"good_user", "missing_user"},
+			videoCount: 20,
+			expectedSnapshots: map[string]statstools.LastNVideoStatistics{
+				"good_user": {VideoCount: 3, ViewCountSum: 300},
+			},
+		},
+		{
+			name:       "A failing streamer does not stop the others",
+			watchlist:  []string{"good_user", "good_user_bad_video_request"},
+			videoCount: 20,
+			expectedSnapshots: map[string]statstools.LastNVideoStatistics{
+				"good_user": {VideoCount: 3, ViewCountSum: 300},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(testutil.StubServerMux())
+			defer server.Close()
+
+			store := storage.NewMemoryStore()
+			c := collector.NewCollector(
+				helixclient.NewClient(helixclient.WithHelixHost(server.URL)),
+				store,
+				tc.watchlist,
+				collector.WithVideoCount(tc.videoCount),
+				collector.WithClock(func() time.Time { return collectedAt }),
+			)
+
+			err := c.CollectOnce(context.Background())
+			if tc.expectError && err == nil {
+				t.Errorf("expected an error, got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			for _, login := range append(tc.watchlist, "good_user_bad_video_request") {
+
+				snapshots := savedSnapshots(t, store, login)
+				expected, ok := tc.expectedSnapshots[login]
+				if !ok {
+					if len(snapshots) != 0 {
+						t.Errorf("expected no snapshots of %s, got %d", login, len(snapshots))
+					}
+					continue
+				}
+
+				if len(snapshots) != 1 {
+					t.Fatalf("expected 1 snapshot of %s, got %d", login, len(snapshots))
+				}
+
+				snapshot := snapshots[0]
+				if snapshot.Login != login || snapshot.UserID == "" || !snapshot.CollectedAt.Equal(collectedAt) {
+					t.Errorf("unexpected snapshot of %s: %+v", login, snapshot)
+				}
+				if snapshot.Statistics.VideoCount != expected.VideoCount || snapshot.Statistics.ViewCountSum != expected.ViewCountSum {
+					t.Errorf("expected %s statistics %+v, got %+v", login, expected, snapshot.Statistics)
+				}
+
+				if _, err := store.Streamer(context.Background(), login); err != nil {
+					t.Errorf("expected %s to be recorded, got %v", login, err)
+				}
+
+				videos, err := store.Videos(context.Background(), snapshot.UserID)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(videos) != expected.VideoCount {
+					t.Errorf("expected %d videos of %s, got %d", expected.VideoCount, login, len(videos))
+				}
+			}
+		})
+	}
+}
+
+func TestCollectOnceConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+
+	var inFlight, maxInFlight atomic.Int32
+
+	mux := testutil.StubServerMux()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == helixclient.HelixVideosEndpoint {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				seen := maxInFlight.Load()
+				if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := collector.NewCollector(
+		helixclient.NewClient(helixclient.WithHelixHost(server.URL)),
+		storage.NewMemoryStore(),
+		[]string{"good_user", testutil.SecondUserName, testutil.NoVideosUserName},
+		collector.WithConcurrency(2),
+	)
+
+	if err := c.CollectOnce(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("expected at most 2 concurrent video requests, got %d", got)
+	}
+}
+
+func TestCollectorStartStop(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(testutil.StubServerMux())
+	defer server.Close()
+
+	store := storage.NewMemoryStore()
+	c := collector.NewCollector(
+		helixclient.NewClient(helixclient.WithHelixHost(server.URL)),
+		store,
+		[]string{"good_user"},
+		collector.WithInterval(10*time.Millisecond),
+	)
+
+	c.Start(context.Background())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(savedSnapshots(t, store, "good_user")) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected repeated snapshots, got %d", len(savedSnapshots(t, store, "good_user")))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.Stop(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stopped := len(savedSnapshots(t, store, "good_user"))
+	time.Sleep(50 * time.Millisecond)
+	if got := len(savedSnapshots(t, store, "good_user")); got != stopped {
+		t.Errorf("expected no snapshots after stop, got %d more", got-stopped)
+	}
+}
@@ -4,4 +4,6 @@ const (
 	ContentTypeHeaderKey       string = "Content-Type"
 	ContentTypeFormURLEndcoded string = "application/x-www-form-urlencoded"
 	ContentTypeApplicationJson string = "application/json"
+	ContentTypeProblemJson     string = "application/problem+json"
+	RetryAfterHeaderKey        string = "Retry-After"
 )
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"ttv-statistics/constants"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/statstools"
+)
+
+const (
+	Users  = "users"
+	RankBy = "rank_by"
+
+	maxComparedStreamers = 25
+	maxComparedVideos    = 100
+	compareParallelism   = 4
+)
+
+type compareStreamersResponse struct {
+	statstools.Comparison
+	NotFound []string `json:"not_found"`
+}
+
+func (h *Handlers) CompareStreamers(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	userNames := parseUserNames(r.URL.Query().Get(Users))
+	if len(userNames) == 0 {
+		writeMissingParameter(w, r, Users)
+		return
+	}
+
+	if len(userNames) > maxComparedStreamers {
+		writeProblem(w, r, http.StatusBadRequest, ErrorCodeInvalidParameter,
+			fmt.Sprintf("%s must contain at most %d logins", Users, maxComparedStreamers))
+		return
+	}
+
+	if r.URL.Query().Get(LastN) == "" {
+		writeMissingParameter(w, r, LastN)
+		return
+	}
+
+	// N is bounded as every compared streamer pages through helix for its videos
+	intN, err := parseBoundedInt(r, LastN, 0, 1, maxComparedVideos)
+	if err != nil {
+		writeInvalidParameter(w, r, err)
+		return
+	}
+
+	filter := helixclient.VideoFilter{
+		Type:   r.URL.Query().Get(VideoType),
+		Period: r.URL.Query().Get(VideoPeriod),
+		Sort:   r.URL.Query().Get(VideoSort),
+	}
+
+	if err := filter.Validate(); err != nil {
+		writeInvalidParameter(w, r, err)
+		return
+	}
+
+	rankBy := statstools.ComparisonMetricViewCountAvg
+	if metric := r.URL.Query().Get(RankBy); metric != "" {
+		rankBy, err = statstools.ParseComparisonMetric(metric)
+		if err != nil {
+			writeInvalidParameter(w, r, err)
+			return
+		}
+	}
+
+	lookups, err := h.helix.LookupUsers(ctx, userNames, nil)
+	if err != nil {
+		writeHelixError(w, r, "error occured obtaining ttv user data", err)
+		return
+	}
+
+	response := compareStreamersResponse{
+		NotFound: []string{},
+	}
+
+	users := []helixclient.UserInfo{}
+	for _, userName := range userNames {
+		lookup := lookups.ByLogin[userName]
+		if !lookup.Found {
+			response.NotFound = append(response.NotFound, userName)
+			continue
+		}
+		users = append(users, lookup.UserInfo)
+	}
+
+	streamers, err := h.aggregateStreamers(ctx, users, intN, filter)
+	if err != nil {
+		writeHelixError(w, r, "error occured obtaining ttv video data", err)
+		return
+	}
+
+	response.Comparison = statstools.CompareStreamers(streamers, rankBy)
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		writeInternalError(w, r, "failed to marshal response body", err)
+		return
+	}
+
+	w.Header().Set(constants.ContentTypeHeaderKey, constants.ContentTypeApplicationJson)
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+}
+
+// aggregateStreamers fetches and aggregates the videos of each user concurrently, with at most
+// compareParallelism upstream requests in flight. The first error cancels the remaining fetches.
+func (h *Handlers) aggregateStreamers(
+	ctx context.Context, users []helixclient.UserInfo, n int, filter helixclient.VideoFilter,
+) ([]statstools.StreamerStatistics, error) {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	streamers := make([]statstools.StreamerStatistics, len(users))
+	semaphore := make(chan struct{}, compareParallelism)
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	for i, user := range users {
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				return
+			}
+
+			statistics, err := h.aggregateStreamer(ctx, user.ID, n, filter)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("message=%q login=%s innermessage=%w", "failed to aggregate streamer", user.Login, err)
+					cancel()
+				})
+				return
+			}
+
+			streamers[i] = statstools.StreamerStatistics{
+				Login:       user.Login,
+				UserID:      user.ID,
+				DisplayName: user.DisplayName,
+				Statistics:  statistics,
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return streamers, nil
+}
+
+func (h *Handlers) aggregateStreamer(
+	ctx context.Context, userID string, n int, filter helixclient.VideoFilter,
+) (statistics statstools.LastNVideoStatistics, err error) {
+
+	videosData, err := h.helix.GetStreamerFirstNVideoStatistics(ctx, userID, n, filter)
+	if err != nil {
+		return statistics, err
+	}
+
+	statistics, err = statstools.AggregateStreamerVideoStatistics(videosData.Data)
+	if err != nil {
+		return statistics, err
+	}
+
+	statistics.Filters = filter.WithDefaults()
+
+	return statistics, nil
+}
+
+// parseUserNames splits a comma separated list of logins, normalising them to lower case as
+// helix logins are case insensitive, and drops blanks and duplicates while preserving order.
+func parseUserNames(users string) []string {
+
+	userNames := []string{}
+	seen := map[string]bool{}
+
+	for _, userName := range strings.Split(users, ",") {
+		userName = strings.ToLower(strings.TrimSpace(userName))
+		if userName == "" || seen[userName] {
+			continue
+		}
+		seen[userName] = true
+		userNames = append(userNames, userName)
+	}
+
+	return userNames
+}
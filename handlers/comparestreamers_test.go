@@ -0,0 +1,186 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"ttv-statistics/handlers"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/statstools"
+	"ttv-statistics/testutil"
+)
+
+func TestCompareStreamers(t *testing.T) {
+
+	stubServer := httptest.NewServer(testutil.StubServerMux())
+	defer stubServer.Close()
+
+	h := handlers.NewHandlers(helixclient.NewClient(
+		helixclient.WithHelixHost(stubServer.URL),
+		helixclient.WithAuthHost(stubServer.URL),
+		helixclient.WithCredentials(testutil.StubClientID, testutil.StubClientSecret),
+	))
+
+	type rankedStreamer struct {
+		Rank            int                     `json:"rank"`
+		Login           string                  `json:"login"`
+		DeltaFromMedian statstools.MetricValues `json:"delta_from_median"`
+	}
+
+	type comparison struct {
+		RankedBy    string                  `json:"ranked_by"`
+		GroupMedian statstools.MetricValues `json:"group_median"`
+		Streamers   []rankedStreamer        `json:"streamers"`
+		NotFound    []string                `json:"not_found"`
+	}
+
+	type testCase struct {
+		name               string
+		queryParams        map[string]string
+		expectedComparison comparison
+		expectedBody       string
+		expectedCode       int
+	}
+
+	tooManyUsers := []string{}
+	for i := range 26 {
+		tooManyUsers = append(tooManyUsers, fmt.Sprintf("user_%d", i))
+	}
+
+	testCases := []testCase{
+		{
+			name:        "Valid comparison ranked by average views",
+			queryParams: map[string]string{"users": "good_user, Second_User,no_videos_user,unknown_user,good_user", "N": "3"},
+			expectedComparison: comparison{
+				RankedBy:    "view_count_avg",
+				GroupMedian: statstools.MetricValues{ViewCountSum: 300, ViewCountAvg: 100, ViewPerMinuteAvg: 5},
+				Streamers: []rankedStreamer{
+					{Rank: 1, Login: testutil.SecondUserName, DeltaFromMedian: statstools.MetricValues{ViewCountSum: 600, ViewCountAvg: 350, ViewPerMinuteAvg: 2.5}},
+					{Rank: 2, Login: "good_user", DeltaFromMedian: statstools.MetricValues{}},
+					{Rank: 3, Login: testutil.NoVideosUserName, DeltaFromMedian: statstools.MetricValues{ViewCountSum: -300, ViewCountAvg: -100, ViewPerMinuteAvg: -5}},
+				},
+				NotFound: []string{"unknown_user"},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:        "Valid comparison ranked by views per minute",
+			queryParams: map[string]string{"users": "good_user,second_user", "N": "3", "rank_by": "view_per_minute_avg"},
+			expectedComparison: comparison{
+				RankedBy:    "view_per_minute_avg",
+				GroupMedian: statstools.MetricValues{ViewCountSum: 600, ViewCountAvg: 275, ViewPerMinuteAvg: 6.25},
+				Streamers: []rankedStreamer{
+					{Rank: 1, Login: testutil.SecondUserName, DeltaFromMedian: statstools.MetricValues{ViewCountSum: 300, ViewCountAvg: 175, ViewPerMinuteAvg: 1.25}},
+					{Rank: 2, Login: "good_user", DeltaFromMedian: statstools.MetricValues{ViewCountSum: -300, ViewCountAvg: -175, ViewPerMinuteAvg: -1.25}},
+				},
+				NotFound: []string{},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:        "No users found",
+			queryParams: map[string]string{"users": "unknown_user", "N": "3"},
+			expectedComparison: comparison{
+				RankedBy:  "view_count_avg",
+				Streamers: []rankedStreamer{},
+				NotFound:  []string{"unknown_user"},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Missing users param",
+			queryParams:  map[string]string{"users": " , ", "N": "3"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeMissingParameter, "Missing required parameter", "missing required URL param users", "/ttv-statistics/compare"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Too many users",
+			queryParams:  map[string]string{"users": strings.Join(tooManyUsers, ","), "N": "3"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "users must contain at most 25 logins", "/ttv-statistics/compare"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Missing N param",
+			queryParams:  map[string]string{"users": "good_user"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeMissingParameter, "Missing required parameter", "missing required URL param N", "/ttv-statistics/compare"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Zero N param",
+			queryParams:  map[string]string{"users": "good_user", "N": "0"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "N must be an integer between 1 and 100", "/ttv-statistics/compare"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "N param above the maximum",
+			queryParams:  map[string]string{"users": "good_user", "N": "101"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "N must be an integer between 1 and 100", "/ttv-statistics/compare"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid rank_by param",
+			queryParams:  map[string]string{"users": "good_user", "N": "3", "rank_by": "followers"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "rank_by must be one of view_count_sum, view_count_avg, view_per_minute_avg", "/ttv-statistics/compare"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "helix client fails to get user data",
+			queryParams:  map[string]string{"users": "good_user,bad_user", "N": "3"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "helix client fails to get video data",
+			queryParams:  map[string]string{"users": "good_user,good_user_bad_video_request", "N": "3"},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			query := url.Values{}
+			for k, v := range tc.queryParams {
+				query.Set(k, v)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/ttv-statistics/compare?"+query.Encode(), nil)
+
+			rec := httptest.NewRecorder()
+			h.CompareStreamers(rec, req)
+
+			resp := rec.Result()
+			defer resp.Body.Close()
+			bodyBytes, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedCode, resp.StatusCode, bodyBytes)
+			}
+
+			if tc.expectedCode != http.StatusOK {
+				bodyStr := strings.Trim(string(bodyBytes), "\n")
+				if tc.expectedBody != "" && bodyStr != tc.expectedBody {
+					t.Errorf("\nwant %q\n got %q", tc.expectedBody, bodyStr)
+				}
+				return
+			}
+
+			var result comparison
+			if err := json.Unmarshal(bodyBytes, &result); err != nil {
+				t.Fatalf("failed to decode response body: %v", err)
+			}
+
+			if result.RankedBy != tc.expectedComparison.RankedBy ||
+				result.GroupMedian != tc.expectedComparison.GroupMedian ||
+				!slices.Equal(result.Streamers, tc.expectedComparison.Streamers) ||
+				!slices.Equal(result.NotFound, tc.expectedComparison.NotFound) {
+				t.Errorf("\nwant %+v\n got %+v", tc.expectedComparison, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"ttv-statistics/constants"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/statstools"
+)
+
+const (
+	Top = "top"
+
+	defaultClipCount = 100
+	maxClipCount     = 1000
+	defaultTopCount  = 5
+	maxTopCount      = 25
+
+	// clipVODCount is the number of the streamer's most recent past broadcasts clips are attributed to
+	clipVODCount = 100
+)
+
+func (h *Handlers) GetStreamerClipStatistics(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+	userName := r.PathValue(UserNamePathParam)
+	if userName == "" {
+		writeProblem(w, r, http.StatusNotFound, ErrorCodeNotFound, fmt.Sprintf("missing required path param %s", UserNamePathParam))
+		return
+	}
+
+	window, err := parseVideoWindow(r)
+	if err != nil {
+		writeInvalidParameter(w, r, err)
+		return
+	}
+
+	if window.Since.IsZero() && !window.Until.IsZero() {
+		writeProblem(w, r, http.StatusBadRequest, ErrorCodeInvalidParameter, fmt.Sprintf("%s must be provided when %s is provided", Since, Until))
+		return
+	}
+
+	intN, err := parseBoundedInt(r, LastN, defaultClipCount, 1, maxClipCount)
+	if err != nil {
+		writeInvalidParameter(w, r, err)
+		return
+	}
+
+	top, err := parseBoundedInt(r, Top, defaultTopCount, 1, maxTopCount)
+	if err != nil {
+		writeInvalidParameter(w, r, err)
+		return
+	}
+
+	userData, err := h.helix.GetUserData(ctx, userName)
+	if err != nil {
+		writeHelixError(w, r, "error occured obtaining ttv user data", err)
+		return
+	}
+
+	if len(userData.Data) == 0 {
+		writeProblem(w, r, http.StatusNotFound, ErrorCodeUserNotFound, fmt.Sprintf("no twitch user found with login %s", userName))
+		return
+	}
+
+	userID := userData.Data[0].ID
+
+	clipsData, err := h.helix.GetStreamerClips(ctx, userID, window, intN)
+	if err != nil {
+		writeHelixError(w, r, "error occured obtaining ttv clip data", err)
+		return
+	}
+
+	vodFilter := helixclient.VideoFilter{Type: helixclient.VideoTypeArchive}
+
+	var vodsData helixclient.VideosResponseBody
+	if window.IsZero() {
+		vodsData, err = h.helix.GetStreamerFirstNVideoStatistics(ctx, userID, clipVODCount, vodFilter)
+	} else {
+		vodsData, err = h.helix.GetStreamerVideosInWindow(ctx, userID, window, clipVODCount, vodFilter)
+	}
+	if err != nil {
+		writeHelixError(w, r, "error occured obtaining ttv video data", err)
+		return
+	}
+
+	statistics := statstools.AggregateStreamerClipStatistics(clipsData.Data, vodsData.Data, top)
+	statistics.Window = window
+
+	payload, err := json.Marshal(statistics)
+	if err != nil {
+		writeInternalError(w, r, "failed to marshal response body", err)
+		return
+	}
+
+	w.Header().Set(constants.ContentTypeHeaderKey, constants.ContentTypeApplicationJson)
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+}
+
+// parseBoundedInt reads the optional integer query parameter name, returning defaultValue when it is
+// omitted.
+func parseBoundedInt(r *http.Request, name string, defaultValue, minValue, maxValue int) (int, error) {
+
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	integer, err := strconv.Atoi(value)
+	if err != nil || integer < minValue || integer > maxValue {
+		return 0, fmt.Errorf("%s must be an integer between %d and %d", name, minValue, maxValue)
+	}
+
+	return integer, nil
+}
@@ -0,0 +1,122 @@
+package handlers_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"ttv-statistics/handlers"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/testutil"
+)
+
+const (
+	// top clips of the stub clips served for good_user, most viewed first
+	topClip1 = `{"id":"c1","title":"Clip 1","url":"https://clips.twitch.tv/c1","creator_name":"clipper_a","view_count":500,"created_at":"2025-06-03T13:00:00Z"}`
+	topClip2 = `{"id":"c2","title":"Clip 2","url":"https://clips.twitch.tv/c2","creator_name":"clipper_b","view_count":300,"created_at":"2025-06-03T14:00:00Z"}`
+	topClip3 = `{"id":"c3","title":"Clip 3","url":"https://clips.twitch.tv/c3","creator_name":"clipper_a","view_count":200,"created_at":"2025-06-02T13:00:00Z"}`
+)
+
+func TestGetStreamerClipStatistics(t *testing.T) {
+
+	stubServer := httptest.NewServer(testutil.StubServerMux())
+	defer stubServer.Close()
+
+	h := handlers.NewHandlers(helixclient.NewClient(helixclient.WithHelixHost(stubServer.URL)))
+
+	type testCase struct {
+		name         string
+		userName     string
+		queryParams  map[string]string
+		expectedBody string
+		expectedCode int
+	}
+
+	testCases := []testCase{
+		{
+			name:         "Valid request",
+			userName:     "good_user",
+			queryParams:  map[string]string{"top": "2"},
+			expectedBody: `{"clip_count":4,"view_count_sum":1050,"top_clips":[` + topClip1 + `,` + topClip2 + `],"top_clippers":[{"creator_id":"clipper_a","creator_name":"clipper_a","clip_count":2,"view_count_sum":700},{"creator_id":"clipper_b","creator_name":"clipper_b","clip_count":1,"view_count_sum":300}],"clips_per_vod":2,"vods":[{"video_id":"v1","title":"Sample Video 1","clip_count":2,"view_count_sum":800}]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Valid request within a date range",
+			userName:     "good_user",
+			queryParams:  map[string]string{"N": "10", "since": "2025-06-02T00:00:00Z", "until": "2025-06-30T00:00:00Z"},
+			expectedBody: `{"clip_count":3,"view_count_sum":1000,"top_clips":[` + topClip1 + `,` + topClip2 + `,` + topClip3 + `],"top_clippers":[{"creator_id":"clipper_a","creator_name":"clipper_a","clip_count":2,"view_count_sum":700},{"creator_id":"clipper_b","creator_name":"clipper_b","clip_count":1,"view_count_sum":300}],"clips_per_vod":2,"vods":[{"video_id":"v1","title":"Sample Video 1","clip_count":2,"view_count_sum":800}],"window":{"since":"2025-06-02T00:00:00Z","until":"2025-06-30T00:00:00Z"}}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Streamer without clips",
+			userName:     testutil.NoVideosUserName,
+			expectedBody: `{"clip_count":0,"view_count_sum":0,"top_clips":[],"top_clippers":[],"clips_per_vod":0,"vods":[]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:     "Unknown streamer",
+			userName: "no_data_user",
+			expectedBody: problemBody(http.StatusNotFound, handlers.ErrorCodeUserNotFound, "Streamer not found",
+				"no twitch user found with login no_data_user", "/streamer/no_data_user/clips"),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:        "Until without since",
+			userName:    "good_user",
+			queryParams: map[string]string{"until": "2025-06-30T00:00:00Z"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter",
+				"since must be provided when until is provided", "/streamer/good_user/clips"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Top out of range",
+			userName:    "good_user",
+			queryParams: map[string]string{"top": "0"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter",
+				"top must be an integer between 1 and 25", "/streamer/good_user/clips"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Invalid N",
+			userName:    "good_user",
+			queryParams: map[string]string{"N": "many"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter",
+				"N must be an integer between 1 and 1000", "/streamer/good_user/clips"),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			urlPath := fmt.Sprintf("/streamer/%s/clips", tc.userName)
+			query := url.Values{}
+			for k, v := range tc.queryParams {
+				query.Set(k, v)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, urlPath+"?"+query.Encode(), nil)
+			req.SetPathValue(handlers.UserNamePathParam, tc.userName)
+
+			rec := httptest.NewRecorder()
+			h.GetStreamerClipStatistics(rec, req)
+
+			resp := rec.Result()
+			defer resp.Body.Close()
+			bodyBytes, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tc.expectedCode {
+				t.Errorf("expected status %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+
+			bodyStr := strings.Trim(string(bodyBytes), "\n")
+
+			if bodyStr != tc.expectedBody {
+				t.Errorf("\nwant %q\n got %q", tc.expectedBody, bodyStr)
+			}
+		})
+	}
+}
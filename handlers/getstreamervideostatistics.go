@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 	"ttv-statistics/constants"
 	"ttv-statistics/helixclient"
 	"ttv-statistics/statstools"
@@ -14,37 +15,93 @@ import (
 const (
 	UserNamePathParam = "username"
 	LastN             = "N"
+	VideoType         = "type"
+	VideoPeriod       = "period"
+	VideoSort         = "sort"
+	Since             = "since"
+	Until             = "until"
+	Precision         = "precision"
+	DurationFormat    = "duration_format"
 )
 
-func GetStreamerVideoStatistics(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) GetStreamerVideoStatistics(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	userName := r.PathValue(UserNamePathParam)
 	if userName == "" {
-		http.Error(w, fmt.Sprintf("message=%s innermessage=%s", "missing required path param", "username"), http.StatusNotFound)
+		writeProblem(w, r, http.StatusNotFound, ErrorCodeNotFound, fmt.Sprintf("missing required path param %s", UserNamePathParam))
 		return
 	}
 
+	window, err := parseVideoWindow(r)
+	if err != nil {
+		writeInvalidParameter(w, r, err)
+		return
+	}
+
+	// N is optional when the videos are selected by a date range, in which case it caps the video count
 	n := r.URL.Query().Get(LastN)
-	if n == "" {
-		http.Error(w, fmt.Sprintf("message=%s innermessage=%s", "missing required URL param", "N"), http.StatusBadRequest)
+	if n == "" && window.IsZero() {
+		writeMissingParameter(w, r, LastN)
 		return
 	}
 
-	intN, err := strconv.Atoi(n)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("message=%s innermessage=%s", "invalid URL param", "N must be a valid integer"), http.StatusBadRequest)
+	intN := 0
+	if n != "" {
+		intN, err = strconv.Atoi(n)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, ErrorCodeInvalidParameter, fmt.Sprintf("%s must be a valid integer", LastN))
+			return
+		}
+		if intN < 1 {
+			writeProblem(w, r, http.StatusBadRequest, ErrorCodeInvalidParameter, fmt.Sprintf("%s must be at least 1", LastN))
+			return
+		}
+	}
+
+	filter := helixclient.VideoFilter{
+		Type:   r.URL.Query().Get(VideoType),
+		Period: r.URL.Query().Get(VideoPeriod),
+		Sort:   r.URL.Query().Get(VideoSort),
+	}
+
+	if err := filter.Validate(); err != nil {
+		writeInvalidParameter(w, r, err)
 		return
 	}
 
-	userData, err := helixclient.GetUserData(ctx, userName)
+	precision := -1
+	if p := r.URL.Query().Get(Precision); p != "" {
+		precision, err = strconv.Atoi(p)
+		if err != nil || precision < 0 || precision > statstools.MaxPrecision {
+			writeProblem(w, r, http.StatusBadRequest, ErrorCodeInvalidParameter,
+				fmt.Sprintf("%s must be an integer between 0 and %d", Precision, statstools.MaxPrecision))
+			return
+		}
+	}
+
+	durationFormat := statstools.DurationFormatNanoseconds
+	if format := r.URL.Query().Get(DurationFormat); format != "" {
+		durationFormat, err = statstools.ParseDurationFormat(format)
+		if err != nil {
+			writeInvalidParameter(w, r, err)
+			return
+		}
+	}
+
+	if !window.IsZero() && filter.Sort != "" && filter.Sort != helixclient.VideoSortTime {
+		writeProblem(w, r, http.StatusBadRequest, ErrorCodeInvalidParameter, "sort must be time when since or until is provided")
+		return
+	}
+
+	userData, err := h.helix.GetUserData(ctx, userName)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("message=%s innermessage=%v", "error occured obtaining ttv user data", err), http.StatusInternalServerError)
+		writeHelixError(w, r, "error occured obtaining ttv user data", err)
 		return
 	}
 
 	if len(userData.Data) == 0 {
-		http.Error(w, fmt.Sprintf("message=%s", "no user data found"), http.StatusNoContent)
+		writeProblem(w, r, http.StatusNotFound, ErrorCodeUserNotFound, fmt.Sprintf("no twitch user found with login %s", userName))
 		return
 	}
 
@@ -52,21 +109,34 @@ func GetStreamerVideoStatistics(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Warning: Helix API returned more than 1 result in User Data array")
 	}
 
-	videosData, err := helixclient.GetStreamerFirstNVideoStatistics(ctx, userData.Data[0].ID, intN)
+	var videosData helixclient.VideosResponseBody
+	if window.IsZero() {
+		videosData, err = h.helix.GetStreamerFirstNVideoStatistics(ctx, userData.Data[0].ID, intN, filter)
+	} else {
+		videosData, err = h.helix.GetStreamerVideosInWindow(ctx, userData.Data[0].ID, window, intN, filter)
+	}
 	if err != nil {
-		http.Error(w, fmt.Sprintf("message=%s innermessage%v", "error occured obtaining ttv video data", err), http.StatusInternalServerError)
+		writeHelixError(w, r, "error occured obtaining ttv video data", err)
 		return
 	}
 
 	aggregateData, err := statstools.AggregateStreamerVideoStatistics(videosData.Data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeInternalError(w, r, "failed to aggregate video statistics", err)
 		return
 	}
 
+	aggregateData.Filters = filter.WithDefaults()
+	aggregateData.Window = window
+	aggregateData.VideoLengthsSum.Format = durationFormat
+
+	if precision >= 0 {
+		aggregateData = aggregateData.WithPrecision(precision)
+	}
+
 	payload, err := json.Marshal(aggregateData)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("message=%s innermessage=%v", "failed to marshal response body", err), http.StatusInternalServerError)
+		writeInternalError(w, r, "failed to marshal response body", err)
 		return
 	}
 
@@ -74,3 +144,22 @@ func GetStreamerVideoStatistics(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write(payload)
 }
+
+func parseVideoWindow(r *http.Request) (window helixclient.VideoWindow, err error) {
+
+	if since := r.URL.Query().Get(Since); since != "" {
+		window.Since, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			return window, fmt.Errorf("%s must be an RFC 3339 timestamp", Since)
+		}
+	}
+
+	if until := r.URL.Query().Get(Until); until != "" {
+		window.Until, err = time.Parse(time.RFC3339, until)
+		if err != nil {
+			return window, fmt.Errorf("%s must be an RFC 3339 timestamp", Until)
+		}
+	}
+
+	return window, window.Validate()
+}
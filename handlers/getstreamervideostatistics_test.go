@@ -13,14 +13,24 @@ import (
 	"ttv-statistics/testutil"
 )
 
+const (
+	// distributions of the stub videos served for good_user: all three, the two most recent and a single video
+	sampleVideosDistribution = `"distribution":{"view_count":{"min":50,"max":150,"median":100,"p25":75,"p75":125,"p90":140,"std_dev":40.824829046386306,"coefficient_of_variation":0.4082482904638631},"duration_seconds":{"min":600,"max":1800,"median":1200,"p25":900,"p75":1500,"p90":1680,"std_dev":489.89794855663564,"coefficient_of_variation":0.408248290463863}}`
+	recentVideosDistribution = `"distribution":{"view_count":{"min":100,"max":150,"median":125,"p25":112.5,"p75":137.5,"p90":145,"std_dev":25,"coefficient_of_variation":0.2},"duration_seconds":{"min":1200,"max":1800,"median":1500,"p25":1350,"p75":1650,"p90":1740,"std_dev":300,"coefficient_of_variation":0.2}}`
+	video1Distribution       = `"distribution":{"view_count":{"min":150,"max":150,"median":150,"p25":150,"p75":150,"p90":150,"std_dev":0,"coefficient_of_variation":0},"duration_seconds":{"min":1800,"max":1800,"median":1800,"p25":1800,"p75":1800,"p90":1800,"std_dev":0,"coefficient_of_variation":0}}`
+	video2Distribution       = `"distribution":{"view_count":{"min":100,"max":100,"median":100,"p25":100,"p75":100,"p90":100,"std_dev":0,"coefficient_of_variation":0},"duration_seconds":{"min":1200,"max":1200,"median":1200,"p25":1200,"p75":1200,"p90":1200,"std_dev":0,"coefficient_of_variation":0}}`
+)
+
 func TestGetStreamerVideoStatistics(t *testing.T) {
 
 	stubServer := httptest.NewServer(testutil.StubServerMux())
-	helixclient.HelixHost = stubServer.URL
 	defer stubServer.Close()
 
-	helixclient.HelixHost = stubServer.URL
-	helixclient.ClientID = "stub-client-id"
+	h := handlers.NewHandlers(helixclient.NewClient(
+		helixclient.WithHelixHost(stubServer.URL),
+		helixclient.WithAuthHost(stubServer.URL),
+		helixclient.WithCredentials(testutil.StubClientID, testutil.StubClientSecret),
+	))
 
 	type testCase struct {
 		name         string
@@ -35,57 +45,179 @@ func TestGetStreamerVideoStatistics(t *testing.T) {
 			name:         "Valid request",
 			userName:     "good_user",
 			queryParams:  map[string]string{"N": "3"},
-			expectedBody: `{"video_lengths_sum":3600000000000,"view_count_sum":300,"view_count_avg":100,"view_per_minute_avg":5,"most_viewed_video":{"title":"Sample Video 1","view_count":150}}`,
+			expectedBody: `{"video_count":3,"video_lengths_sum":3600000000000,"view_count_sum":300,"view_count_avg":100,"view_per_minute_avg":5,"view_count_avg_float":100,"view_per_minute_avg_float":5,"most_viewed_video":{"title":"Sample Video 1","view_count":150},` + sampleVideosDistribution + `,"filters":{"type":"all","period":"all","sort":"time"}}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Valid request authenticated through the OAuth token endpoint",
+			userName:     testutil.AuthorisedUserName,
+			queryParams:  map[string]string{"N": "3"},
+			expectedBody: `{"video_count":3,"video_lengths_sum":3600000000000,"view_count_sum":300,"view_count_avg":100,"view_per_minute_avg":5,"view_count_avg_float":100,"view_per_minute_avg_float":5,"most_viewed_video":{"title":"Sample Video 1","view_count":150},` + sampleVideosDistribution + `,"filters":{"type":"all","period":"all","sort":"time"}}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Valid request filtered to past broadcasts",
+			userName:     "good_user",
+			queryParams:  map[string]string{"N": "3", "type": "archive", "period": "week", "sort": "views"},
+			expectedBody: `{"video_count":1,"video_lengths_sum":1800000000000,"view_count_sum":150,"view_count_avg":150,"view_per_minute_avg":5,"view_count_avg_float":150,"view_per_minute_avg_float":5,"most_viewed_video":{"title":"Sample Video 1","view_count":150},` + video1Distribution + `,"filters":{"type":"archive","period":"week","sort":"views"}}`,
 			expectedCode: http.StatusOK,
 		},
+		{
+			name:         "Valid request within a date range",
+			userName:     "good_user",
+			queryParams:  map[string]string{"since": "2025-06-02T00:00:00Z", "until": "2025-06-30T00:00:00Z"},
+			expectedBody: `{"video_count":2,"video_lengths_sum":3000000000000,"view_count_sum":250,"view_count_avg":125,"view_per_minute_avg":5,"view_count_avg_float":125,"view_per_minute_avg_float":5,"most_viewed_video":{"title":"Sample Video 1","view_count":150},` + recentVideosDistribution + `,"filters":{"type":"all","period":"all","sort":"time"},"window":{"since":"2025-06-02T00:00:00Z","until":"2025-06-30T00:00:00Z"}}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Valid request within a date range capped at N videos",
+			userName:     "good_user",
+			queryParams:  map[string]string{"N": "1", "until": "2025-06-02T23:00:00Z"},
+			expectedBody: `{"video_count":1,"video_lengths_sum":1200000000000,"view_count_sum":100,"view_count_avg":100,"view_per_minute_avg":5,"view_count_avg_float":100,"view_per_minute_avg_float":5,"most_viewed_video":{"title":"Sample Video 2","view_count":100},` + video2Distribution + `,"filters":{"type":"all","period":"all","sort":"time"},"window":{"until":"2025-06-02T23:00:00Z"}}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid since param",
+			userName:     "good_user",
+			queryParams:  map[string]string{"since": "2025-06-02"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "since must be an RFC 3339 timestamp", "/streamer/good_user/statistics"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Since after until",
+			userName:     "good_user",
+			queryParams:  map[string]string{"since": "2025-06-03T00:00:00Z", "until": "2025-06-02T00:00:00Z"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "since must not be after until", "/streamer/good_user/statistics"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Date range with a non time sort",
+			userName:     "good_user",
+			queryParams:  map[string]string{"since": "2025-06-02T00:00:00Z", "sort": "views"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "sort must be time when since or until is provided", "/streamer/good_user/statistics"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Valid request rounded to a precision",
+			userName:     "good_user",
+			queryParams:  map[string]string{"N": "3", "precision": "2"},
+			expectedBody: `{"video_count":3,"video_lengths_sum":3600000000000,"view_count_sum":300,"view_count_avg":100,"view_per_minute_avg":5,"view_count_avg_float":100,"view_per_minute_avg_float":5,"most_viewed_video":{"title":"Sample Video 1","view_count":150},"distribution":{"view_count":{"min":50,"max":150,"median":100,"p25":75,"p75":125,"p90":140,"std_dev":40.82,"coefficient_of_variation":0.41},"duration_seconds":{"min":600,"max":1800,"median":1200,"p25":900,"p75":1500,"p90":1680,"std_dev":489.9,"coefficient_of_variation":0.41}},"filters":{"type":"all","period":"all","sort":"time"}}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid precision param",
+			userName:     "good_user",
+			queryParams:  map[string]string{"N": "3", "precision": "11"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "precision must be an integer between 0 and 10", "/streamer/good_user/statistics"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Valid request with an ISO 8601 duration",
+			userName:     "good_user",
+			queryParams:  map[string]string{"N": "3", "duration_format": "iso8601"},
+			expectedBody: `{"video_count":3,"video_lengths_sum":"PT1H","view_count_sum":300,"view_count_avg":100,"view_per_minute_avg":5,"view_count_avg_float":100,"view_per_minute_avg_float":5,"most_viewed_video":{"title":"Sample Video 1","view_count":150},` + sampleVideosDistribution + `,"filters":{"type":"all","period":"all","sort":"time"}}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Valid request with a duration in seconds",
+			userName:     "good_user",
+			queryParams:  map[string]string{"N": "3", "duration_format": "seconds"},
+			expectedBody: `{"video_count":3,"video_lengths_sum":3600,"view_count_sum":300,"view_count_avg":100,"view_per_minute_avg":5,"view_count_avg_float":100,"view_per_minute_avg_float":5,"most_viewed_video":{"title":"Sample Video 1","view_count":150},` + sampleVideosDistribution + `,"filters":{"type":"all","period":"all","sort":"time"}}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid duration_format param",
+			userName:     "good_user",
+			queryParams:  map[string]string{"N": "3", "duration_format": "hours"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "duration_format must be one of ns, seconds, iso8601, go", "/streamer/good_user/statistics"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid type param",
+			userName:     "good_user",
+			queryParams:  map[string]string{"N": "3", "type": "clip"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "type must be one of all, archive, highlight, upload", "/streamer/good_user/statistics"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid period param",
+			userName:     "good_user",
+			queryParams:  map[string]string{"N": "3", "period": "year"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "period must be one of all, day, week, month", "/streamer/good_user/statistics"),
+			expectedCode: http.StatusBadRequest,
+		},
 		{
 			name:         "Missing N param",
 			userName:     "good_user",
 			queryParams:  map[string]string{},
-			expectedBody: `message=missing required URL param innermessage=N`,
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeMissingParameter, "Missing required parameter", "missing required URL param N", "/streamer/good_user/statistics"),
 			expectedCode: http.StatusBadRequest,
 		},
 		{
 			name:         "Invalid N param",
 			userName:     "good_user",
 			queryParams:  map[string]string{"N": "abc"},
-			expectedBody: `message=invalid URL param innermessage=N must be a valid integer`,
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "N must be a valid integer", "/streamer/good_user/statistics"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Zero N param",
+			userName:     "good_user",
+			queryParams:  map[string]string{"N": "0"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "N must be at least 1", "/streamer/good_user/statistics"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Negative N param",
+			userName:     "good_user",
+			queryParams:  map[string]string{"N": "-1"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter", "N must be at least 1", "/streamer/good_user/statistics"),
 			expectedCode: http.StatusBadRequest,
 		},
 		{
 			name:         "Missing username",
 			userName:     "",
 			queryParams:  map[string]string{"N": "3"},
-			expectedBody: `message=missing required path param innermessage=username`,
+			expectedBody: problemBody(http.StatusNotFound, handlers.ErrorCodeNotFound, "Not found", "missing required path param username", "/streamer//statistics"),
 			expectedCode: http.StatusNotFound,
 		},
 		{
-			name:         "helix client fails to get user data",
-			userName:     "bad_user",
-			queryParams:  map[string]string{"N": "3"},
-			expectedBody: fmt.Sprintf(`message=error occured obtaining ttv user data innermessage=message=received unexpected status code url=%s/users?login=bad_user status_code=400`, stubServer.URL),
-			expectedCode: http.StatusInternalServerError,
+			name:        "helix client fails to get user data",
+			userName:    "bad_user",
+			queryParams: map[string]string{"N": "3"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeUpstreamBadRequest, "Twitch rejected the request",
+				fmt.Sprintf(`error occured obtaining ttv user data: message=received unexpected status code url=%s/users?login=bad_user status_code=400`, stubServer.URL), "/streamer/bad_user/statistics"),
+			expectedCode: http.StatusBadRequest,
 		},
 		{
-			name:         "helix client fails to get user data",
-			userName:     "no_data_user",
+			name:        "helix client fails to get user data",
+			userName:    "no_data_user",
+			queryParams: map[string]string{"N": "3"},
+			expectedBody: problemBody(http.StatusNotFound, handlers.ErrorCodeUserNotFound, "Streamer not found",
+				"no twitch user found with login no_data_user", "/streamer/no_data_user/statistics"),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "streamer without videos",
+			userName:     testutil.NoVideosUserName,
 			queryParams:  map[string]string{"N": "3"},
-			expectedBody: `message=no user data found`,
-			expectedCode: http.StatusNoContent,
+			expectedBody: `{"video_count":0,"video_lengths_sum":0,"view_count_sum":0,"view_count_avg":0,"view_per_minute_avg":0,"view_count_avg_float":0,"view_per_minute_avg_float":0,"most_viewed_video":{"title":"","view_count":0},"distribution":{"view_count":{"min":0,"max":0,"median":0,"p25":0,"p75":0,"p90":0,"std_dev":0,"coefficient_of_variation":0},"duration_seconds":{"min":0,"max":0,"median":0,"p25":0,"p75":0,"p90":0,"std_dev":0,"coefficient_of_variation":0}},"filters":{"type":"all","period":"all","sort":"time"}}`,
+			expectedCode: http.StatusOK,
 		},
 		{
 			name:         "helix client fails to get user data",
 			userName:     "extra_data_user",
 			queryParams:  map[string]string{"N": "3"},
-			expectedBody: `{"video_lengths_sum":3600000000000,"view_count_sum":300,"view_count_avg":100,"view_per_minute_avg":5,"most_viewed_video":{"title":"Sample Video 1","view_count":150}}`,
+			expectedBody: `{"video_count":3,"video_lengths_sum":3600000000000,"view_count_sum":300,"view_count_avg":100,"view_per_minute_avg":5,"view_count_avg_float":100,"view_per_minute_avg_float":5,"most_viewed_video":{"title":"Sample Video 1","view_count":150},` + sampleVideosDistribution + `,"filters":{"type":"all","period":"all","sort":"time"}}`,
 			expectedCode: http.StatusOK,
 		},
 		{
-			name:         "helix client fails to get user data",
-			userName:     "good_user_bad_video_request",
-			queryParams:  map[string]string{"N": "3"},
-			expectedBody: fmt.Sprintf(`message=error occured obtaining ttv video data innermessagemessage=received unexpected status code url=%s/videos?first=3&user_id=00000 status_code=400`, stubServer.URL),
-			expectedCode: http.StatusInternalServerError,
+			name:        "helix client fails to get user data",
+			userName:    "good_user_bad_video_request",
+			queryParams: map[string]string{"N": "3"},
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeUpstreamBadRequest, "Twitch rejected the request",
+				fmt.Sprintf(`error occured obtaining ttv video data: message=received unexpected status code url=%s/videos?first=3&user_id=00000 status_code=400`, stubServer.URL), "/streamer/good_user_bad_video_request/statistics"),
+			expectedCode: http.StatusBadRequest,
 		},
 	}
 
@@ -102,7 +234,7 @@ func TestGetStreamerVideoStatistics(t *testing.T) {
 			req.SetPathValue(handlers.UserNamePathParam, tc.userName)
 
 			rec := httptest.NewRecorder()
-			handlers.GetStreamerVideoStatistics(rec, req)
+			h.GetStreamerVideoStatistics(rec, req)
 
 			resp := rec.Result()
 			defer resp.Body.Close()
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/storage"
+)
+
+// Handlers holds the dependencies shared by the ttv-statistics HTTP handlers.
+type Handlers struct {
+	helix helixclient.API
+	store storage.Repository
+	now   func() time.Time
+}
+
+type Option func(*Handlers)
+
+func WithClock(now func() time.Time) Option {
+	return func(h *Handlers) {
+		h.now = now
+	}
+}
+
+// WithRepository sets the store history is read from, an empty in-memory store by default.
+func WithRepository(store storage.Repository) Option {
+	return func(h *Handlers) {
+		h.store = store
+	}
+}
+
+func NewHandlers(helix helixclient.API, opts ...Option) *Handlers {
+
+	h := &Handlers{
+		helix: helix,
+		store: storage.NewMemoryStore(),
+		now:   time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// NotFound responds to requests for paths no endpoint is registered for.
+func (h *Handlers) NotFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusNotFound, ErrorCodeNotFound, "no endpoint is registered for this path")
+}
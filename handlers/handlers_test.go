@@ -0,0 +1,188 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"ttv-statistics/handlers"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/testutil"
+)
+
+// problemBody returns the application/problem+json body written for a problem
+func problemBody(status int, code handlers.ErrorCode, title, detail, instance string) string {
+	return fmt.Sprintf(`{"type":"urn:ttv-statistics:problem:%s","title":%q,"status":%d,"detail":%q,"instance":%q,"code":%q}`,
+		code, title, status, detail, instance, code)
+}
+
+func TestNotFound(t *testing.T) {
+
+	req := httptest.NewRequest(http.MethodGet, "/ttv-statistics/unknown", nil)
+	rec := httptest.NewRecorder()
+	handlers.NewHandlers(helixclient.NewClient()).NotFound(rec, req)
+
+	resp := rec.Result()
+	defer resp.Body.Close()
+	bodyBytes, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("expected problem content type, got %q", got)
+	}
+
+	expectedBody := problemBody(http.StatusNotFound, handlers.ErrorCodeNotFound, "Not found", "no endpoint is registered for this path", "/ttv-statistics/unknown")
+	if bodyStr := strings.Trim(string(bodyBytes), "\n"); bodyStr != expectedBody {
+		t.Errorf("\nwant %q\n got %q", expectedBody, bodyStr)
+	}
+}
+
+func TestHelixErrorStatuses(t *testing.T) {
+
+	type testCase struct {
+		name               string
+		helixStatus        int
+		helixBody          string
+		helixHeader        http.Header
+		helixDelay         time.Duration
+		tokenStatus        int
+		tokenBody          string
+		clientGone         bool
+		expectedCode       int
+		expectedErrorCode  handlers.ErrorCode
+		expectedRetryAfter string
+		expectedInDetail   string
+	}
+
+	testCases := []testCase{
+		{
+			name:              "Rejected credentials are a bad gateway",
+			helixStatus:       http.StatusUnauthorized,
+			helixBody:         `{"error":"Unauthorized","status":401,"message":"Invalid OAuth token"}`,
+			expectedCode:      http.StatusBadGateway,
+			expectedErrorCode: handlers.ErrorCodeUpstreamUnauthorised,
+			expectedInDetail:  `helix_error="Unauthorized" helix_message="Invalid OAuth token"`,
+		},
+		{
+			name:              "Not found is passed through",
+			helixStatus:       http.StatusNotFound,
+			helixBody:         `{"error":"Not Found","status":404,"message":"not found"}`,
+			expectedCode:      http.StatusNotFound,
+			expectedErrorCode: handlers.ErrorCodeUpstreamNotFound,
+			expectedInDetail:  `status_code=404`,
+		},
+		{
+			name:              "Refused access token is a bad gateway",
+			helixStatus:       http.StatusUnauthorized,
+			tokenStatus:       http.StatusBadRequest,
+			tokenBody:         `{"status":400,"message":"invalid client"}`,
+			expectedCode:      http.StatusBadGateway,
+			expectedErrorCode: handlers.ErrorCodeUpstreamUnauthorised,
+			expectedInDetail:  `status_code=400`,
+		},
+		{
+			name:               "Rate limiting is service unavailable",
+			helixStatus:        http.StatusTooManyRequests,
+			helixHeader:        http.Header{"Retry-After": {"7"}},
+			expectedCode:       http.StatusServiceUnavailable,
+			expectedErrorCode:  handlers.ErrorCodeUpstreamRateLimited,
+			expectedRetryAfter: "7",
+			expectedInDetail:   `status_code=429`,
+		},
+		{
+			name:              "Helix failure is a bad gateway",
+			helixStatus:       http.StatusInternalServerError,
+			expectedCode:      http.StatusBadGateway,
+			expectedErrorCode: handlers.ErrorCodeUpstreamError,
+			expectedInDetail:  `status_code=500`,
+		},
+		{
+			name:              "Helix timeout is a gateway timeout",
+			helixStatus:       http.StatusOK,
+			helixDelay:        time.Millisecond * 200,
+			expectedCode:      http.StatusGatewayTimeout,
+			expectedErrorCode: handlers.ErrorCodeUpstreamTimeout,
+			expectedInDetail:  `failed to execute http request`,
+		},
+		{
+			name:              "Client disconnecting is not a server fault",
+			helixStatus:       http.StatusOK,
+			clientGone:        true,
+			expectedCode:      handlers.StatusClientClosedRequest,
+			expectedErrorCode: handlers.ErrorCodeClientClosedRequest,
+			expectedInDetail:  context.Canceled.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			mux := testutil.StubServerMux()
+			helixServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == helixclient.HelixTokenEndpoint && tc.tokenStatus != 0 {
+					w.WriteHeader(tc.tokenStatus)
+					w.Write([]byte(tc.tokenBody))
+					return
+				}
+				if r.URL.Path == helixclient.HelixTokenEndpoint {
+					mux.ServeHTTP(w, r)
+					return
+				}
+				time.Sleep(tc.helixDelay)
+				for key, values := range tc.helixHeader {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(tc.helixStatus)
+				w.Write([]byte(tc.helixBody))
+			}))
+			defer helixServer.Close()
+
+			h := handlers.NewHandlers(helixclient.NewClient(
+				helixclient.WithHelixHost(helixServer.URL),
+				helixclient.WithAuthHost(helixServer.URL),
+				helixclient.WithCredentials(testutil.StubClientID, testutil.StubClientSecret),
+				helixclient.WithHTTPClient(&http.Client{Timeout: time.Millisecond * 50}),
+				helixclient.WithRetryPolicy(helixclient.RetryPolicy{MaxAttempts: 1}),
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "/ttv-statistics/getstreamervideostatistics/good_user?N=3", nil)
+			req.SetPathValue(handlers.UserNamePathParam, "good_user")
+			if tc.clientGone {
+				ctx, cancel := context.WithCancel(req.Context())
+				cancel()
+				req = req.WithContext(ctx)
+			}
+			rec := httptest.NewRecorder()
+			h.GetStreamerVideoStatistics(rec, req)
+
+			resp := rec.Result()
+			defer resp.Body.Close()
+			bodyBytes, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tc.expectedCode {
+				t.Errorf("expected status %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+			if got := resp.Header.Get("Retry-After"); got != tc.expectedRetryAfter {
+				t.Errorf("expected Retry-After %q, got %q", tc.expectedRetryAfter, got)
+			}
+
+			var problem handlers.Problem
+			if err := json.Unmarshal(bodyBytes, &problem); err != nil {
+				t.Fatalf("failed to decode problem: %v", err)
+			}
+			if problem.Status != tc.expectedCode || problem.Code != tc.expectedErrorCode {
+				t.Errorf("expected problem status %d code %s, got status %d code %s", tc.expectedCode, tc.expectedErrorCode, problem.Status, problem.Code)
+			}
+			if !strings.Contains(problem.Detail, tc.expectedInDetail) {
+				t.Errorf("expected detail to contain %q, got %q", tc.expectedInDetail, problem.Detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"ttv-statistics/constants"
+	"ttv-statistics/helixclient"
+)
+
+type healthResponse struct {
+	Status              string                          `json:"status"`
+	HelixCircuitBreaker helixclient.CircuitBreakerState `json:"helix_circuit_breaker"`
+	HelixCache          *helixclient.CacheStats         `json:"helix_cache,omitempty"`
+}
+
+// cacheStatsReporter is implemented by helix clients that cache responses, such as CachedClient
+type cacheStatsReporter interface {
+	Stats() helixclient.CacheStats
+}
+
+// Health reports whether requests are reaching helix, and how the helix cache is performing when
+// responses are cached. It always responds 200 OK, as an open circuit breaker means Twitch is degraded
+// rather than this service, so instances should not be restarted.
+func (h *Handlers) Health(w http.ResponseWriter, r *http.Request) {
+
+	response := healthResponse{
+		Status:              "ok",
+		HelixCircuitBreaker: h.helix.CircuitBreakerState(),
+	}
+
+	if cached, ok := h.helix.(cacheStatsReporter); ok {
+		stats := cached.Stats()
+		response.HelixCache = &stats
+	}
+
+	if response.HelixCircuitBreaker != helixclient.CircuitBreakerClosed {
+		response.Status = "degraded"
+	}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		writeInternalError(w, r, "failed to marshal response body", err)
+		return
+	}
+
+	w.Header().Set(constants.ContentTypeHeaderKey, constants.ContentTypeApplicationJson)
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+}
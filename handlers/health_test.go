@@ -0,0 +1,159 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"ttv-statistics/handlers"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/testutil"
+)
+
+// newOpenCircuitClient returns a client whose circuit breaker has been opened by a failing helix.
+func newOpenCircuitClient(t *testing.T) *helixclient.Client {
+	t.Helper()
+
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failingServer.Close)
+
+	client := helixclient.NewClient(
+		helixclient.WithHelixHost(failingServer.URL),
+		helixclient.WithRetryPolicy(helixclient.RetryPolicy{MaxAttempts: 1}),
+		helixclient.WithCircuitBreakerPolicy(helixclient.CircuitBreakerPolicy{
+			WindowSize:   1,
+			MinRequests:  1,
+			FailureRate:  1,
+			OpenDuration: time.Minute,
+		}),
+	)
+
+	client.GetUserData(context.Background(), "good_user")
+
+	return client
+}
+
+// newCachedClient returns a cached client that has looked good_user up twice, missing and then hitting.
+func newCachedClient(t *testing.T) *helixclient.CachedClient {
+	t.Helper()
+
+	stubServer := httptest.NewServer(testutil.StubServerMux())
+	t.Cleanup(stubServer.Close)
+
+	client := helixclient.NewCachedClient(helixclient.NewClient(helixclient.WithHelixHost(stubServer.URL)))
+
+	for range 2 {
+		if _, err := client.GetUserData(context.Background(), "good_user"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	return client
+}
+
+func TestHealth(t *testing.T) {
+
+	type testCase struct {
+		name         string
+		client       helixclient.API
+		expectedBody string
+	}
+
+	testCases := []testCase{
+		{
+			name:         "Closed circuit breaker is healthy",
+			client:       helixclient.NewClient(),
+			expectedBody: `{"status":"ok","helix_circuit_breaker":"closed"}`,
+		},
+		{
+			name:         "Open circuit breaker is degraded",
+			client:       newOpenCircuitClient(t),
+			expectedBody: `{"status":"degraded","helix_circuit_breaker":"open"}`,
+		},
+		{
+			name:   "Cached client reports its cache statistics",
+			client: newCachedClient(t),
+			expectedBody: `{"status":"ok","helix_circuit_breaker":"closed","helix_cache":{"user_hits":1,"user_misses":1,"user_entries":1,` +
+				`"video_hits":0,"video_misses":0,"video_entries":0,"clip_hits":0,"clip_misses":0,"clip_entries":0}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			req := httptest.NewRequest(http.MethodGet, "/ttv-statistics/health", nil)
+			rec := httptest.NewRecorder()
+			handlers.NewHandlers(tc.client).Health(rec, req)
+
+			resp := rec.Result()
+			defer resp.Body.Close()
+			bodyBytes, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+			}
+
+			if bodyStr := string(bodyBytes); bodyStr != tc.expectedBody {
+				t.Errorf("\nwant %q\n got %q", tc.expectedBody, bodyStr)
+			}
+		})
+	}
+}
+
+func TestOpenCircuitFailsFast(t *testing.T) {
+
+	h := handlers.NewHandlers(newOpenCircuitClient(t))
+
+	type testCase struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+	}
+
+	testCases := []testCase{
+		{
+			name:    "Streamer video statistics",
+			handler: h.GetStreamerVideoStatistics,
+			url:     "/ttv-statistics/getstreamervideostatistics/good_user?N=3",
+		},
+		{
+			name:    "Compare streamers",
+			handler: h.CompareStreamers,
+			url:     "/ttv-statistics/compare?users=good_user,second_user&N=3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			req.SetPathValue(handlers.UserNamePathParam, "good_user")
+			rec := httptest.NewRecorder()
+			tc.handler(rec, req)
+
+			resp := rec.Result()
+			defer resp.Body.Close()
+			bodyBytes, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != http.StatusServiceUnavailable {
+				t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, resp.StatusCode)
+			}
+			if got := resp.Header.Get("Retry-After"); got != "60" {
+				t.Errorf("expected Retry-After 60, got %q", got)
+			}
+
+			var problem handlers.Problem
+			if err := json.Unmarshal(bodyBytes, &problem); err != nil {
+				t.Fatalf("failed to decode problem: %v", err)
+			}
+			if problem.Code != handlers.ErrorCodeUpstreamUnavailable {
+				t.Errorf("expected problem code %s, got %s", handlers.ErrorCodeUpstreamUnavailable, problem.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"ttv-statistics/constants"
+	"ttv-statistics/statstools"
+	"ttv-statistics/storage"
+)
+
+const (
+	Metric = "metric"
+	From   = "from"
+	To     = "to"
+	Bucket = "bucket"
+	Fill   = "fill"
+
+	defaultHistoryMetric = "view_count_sum"
+	defaultHistoryBucket = "1d"
+	defaultHistoryRange  = 30 * 24 * time.Hour
+	minHistoryBucket     = time.Minute
+	maxHistoryBucket     = 365 * 24 * time.Hour
+	maxHistoryBuckets    = 1000
+)
+
+type historyResponse struct {
+	Login   string                     `json:"login"`
+	UserID  string                     `json:"user_id"`
+	Metric  statstools.HistoryMetric   `json:"metric"`
+	From    time.Time                  `json:"from"`
+	To      time.Time                  `json:"to"`
+	Bucket  string                     `json:"bucket"`
+	Fill    statstools.GapFill         `json:"fill"`
+	Buckets []statstools.HistoryBucket `json:"buckets"`
+}
+
+// GetStreamerHistory returns a metric of the snapshots recorded for a streamer, summarised over buckets
+// of time. Only streamers that have been snapshotted have a history.
+func (h *Handlers) GetStreamerHistory(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+	userName := r.PathValue(UserNamePathParam)
+	if userName == "" {
+		writeProblem(w, r, http.StatusNotFound, ErrorCodeNotFound, fmt.Sprintf("missing required path param %s", UserNamePathParam))
+		return
+	}
+
+	query := r.URL.Query()
+
+	metric, err := statstools.ParseHistoryMetric(cmp.Or(query.Get(Metric), defaultHistoryMetric))
+	if err != nil {
+		writeInvalidParameter(w, r, err)
+		return
+	}
+
+	fill, err := statstools.ParseGapFill(cmp.Or(query.Get(Fill), string(statstools.GapFillNull)))
+	if err != nil {
+		writeInvalidParameter(w, r, err)
+		return
+	}
+
+	bucketValue := cmp.Or(query.Get(Bucket), defaultHistoryBucket)
+	bucket, err := parseBucket(bucketValue)
+	if err != nil {
+		writeInvalidParameter(w, r, err)
+		return
+	}
+
+	to := h.now().UTC()
+	if value := query.Get(To); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, ErrorCodeInvalidParameter, fmt.Sprintf("%s must be an RFC 3339 timestamp", To))
+			return
+		}
+	}
+
+	from := to.Add(-defaultHistoryRange)
+	if value := query.Get(From); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, ErrorCodeInvalidParameter, fmt.Sprintf("%s must be an RFC 3339 timestamp", From))
+			return
+		}
+	}
+
+	if !from.Before(to) {
+		writeProblem(w, r, http.StatusBadRequest, ErrorCodeInvalidParameter, fmt.Sprintf("%s must be before %s", From, To))
+		return
+	}
+
+	if statstools.HistoryBucketCount(from, to, bucket) > maxHistoryBuckets {
+		writeProblem(w, r, http.StatusBadRequest, ErrorCodeInvalidParameter,
+			fmt.Sprintf("%s and %s must span at most %d buckets", From, To, maxHistoryBuckets))
+		return
+	}
+
+	streamer, err := h.store.Streamer(ctx, userName)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeProblem(w, r, http.StatusNotFound, ErrorCodeUserNotFound, fmt.Sprintf("no history recorded for streamer %s", userName))
+		return
+	}
+	if err != nil {
+		writeInternalError(w, r, "failed to read streamer", err)
+		return
+	}
+
+	snapshots, err := h.store.Snapshots(ctx, streamer.Login, from, to)
+	if err != nil {
+		writeInternalError(w, r, "failed to read snapshots", err)
+		return
+	}
+
+	response := historyResponse{
+		Login:   streamer.Login,
+		UserID:  streamer.ID,
+		Metric:  metric,
+		From:    from.UTC(),
+		To:      to.UTC(),
+		Bucket:  bucketValue,
+		Fill:    fill,
+		Buckets: statstools.DownsampleSnapshots(snapshots, metric, from, to, bucket, fill),
+	}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		writeInternalError(w, r, "failed to marshal response body", err)
+		return
+	}
+
+	w.Header().Set(constants.ContentTypeHeaderKey, constants.ContentTypeApplicationJson)
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+}
+
+// parseBucket reads a bucket length as a whole number of days, such as 7d, or a Go duration such as 6h
+func parseBucket(value string) (time.Duration, error) {
+
+	invalid := fmt.Errorf("%s must be a number of days such as 1d, or a duration such as 6h, between 1m and 365d", Bucket)
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.Atoi(days)
+		if err != nil || count < 1 || count > int(maxHistoryBucket/(24*time.Hour)) {
+			return 0, invalid
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+
+	bucket, err := time.ParseDuration(value)
+	if err != nil || bucket < minHistoryBucket || bucket > maxHistoryBucket {
+		return 0, invalid
+	}
+
+	return bucket, nil
+}
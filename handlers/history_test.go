@@ -0,0 +1,182 @@
+package handlers_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"ttv-statistics/handlers"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/statstools"
+	"ttv-statistics/storage"
+)
+
+func TestGetStreamerHistory(t *testing.T) {
+
+	now := time.Date(2025, time.July, 4, 6, 0, 0, 0, time.UTC)
+
+	store := storage.NewMemoryStore()
+	ctx := context.Background()
+
+	if err := store.SaveStreamer(ctx, helixclient.UserInfo{ID: "good_user", Login: "good_user", DisplayName: "Streamer A"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, snapshot := range []struct {
+		collectedAt  time.Time
+		viewCountSum int
+	}{
+		{time.Date(2025, time.July, 1, 6, 0, 0, 0, time.UTC), 100},
+		{time.Date(2025, time.July, 1, 18, 0, 0, 0, time.UTC), 300},
+		{time.Date(2025, time.July, 3, 6, 0, 0, 0, time.UTC), 500},
+	} {
+		err := store.SaveSnapshot(ctx, statstools.Snapshot{
+			Login:       "good_user",
+			UserID:      "good_user",
+			CollectedAt: snapshot.collectedAt,
+			Statistics: statstools.LastNVideoStatistics{
+				VideoCount:      2,
+				VideoLengthsSum: statstools.Duration{Duration: time.Hour},
+				ViewCountSum:    snapshot.viewCountSum,
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	h := handlers.NewHandlers(
+		helixclient.NewClient(),
+		handlers.WithRepository(store),
+		handlers.WithClock(func() time.Time { return now }),
+	)
+
+	const (
+		firstDay  = `{"start":"2025-07-01T00:00:00Z","snapshot_count":2,"min":100,"max":300,"avg":200}`
+		emptyDay  = `{"start":"2025-07-02T00:00:00Z","snapshot_count":0,"min":null,"max":null,"avg":null}`
+		filledDay = `{"start":"2025-07-02T00:00:00Z","snapshot_count":0,"min":100,"max":300,"avg":200}`
+		thirdDay  = `{"start":"2025-07-03T00:00:00Z","snapshot_count":1,"min":500,"max":500,"avg":500}`
+	)
+
+	type testCase struct {
+		name         string
+		userName     string
+		query        string
+		expectedBody string
+		expectedCode int
+	}
+
+	testCases := []testCase{
+		{
+			name:     "Daily buckets with gaps left null",
+			userName: "good_user",
+			query:    "from=2025-07-01T00:00:00Z&to=2025-07-04T00:00:00Z",
+			expectedBody: `{"login":"good_user","user_id":"good_user","metric":"view_count_sum","from":"2025-07-01T00:00:00Z","to":"2025-07-04T00:00:00Z",` +
+				`"bucket":"1d","fill":"null","buckets":[` + firstDay + `,` + emptyDay + `,` + thirdDay + `]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:     "Gaps carry the previous bucket forward",
+			userName: "Good_User",
+			query:    "from=2025-07-01T00:00:00Z&to=2025-07-04T00:00:00Z&fill=previous",
+			expectedBody: `{"login":"good_user","user_id":"good_user","metric":"view_count_sum","from":"2025-07-01T00:00:00Z","to":"2025-07-04T00:00:00Z",` +
+				`"bucket":"1d","fill":"previous","buckets":[` + firstDay + `,` + filledDay + `,` + thirdDay + `]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:     "Durations are reported in seconds",
+			userName: "good_user",
+			query:    "metric=video_lengths_sum&from=2025-07-01T00:00:00Z&to=2025-07-02T00:00:00Z&bucket=12h",
+			expectedBody: `{"login":"good_user","user_id":"good_user","metric":"video_lengths_sum","from":"2025-07-01T00:00:00Z","to":"2025-07-02T00:00:00Z",` +
+				`"bucket":"12h","fill":"null","buckets":[` +
+				`{"start":"2025-07-01T00:00:00Z","snapshot_count":1,"min":3600,"max":3600,"avg":3600},` +
+				`{"start":"2025-07-01T12:00:00Z","snapshot_count":1,"min":3600,"max":3600,"avg":3600}]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:     "Range defaults to the 30 days before now and weeks start on Monday",
+			userName: "good_user",
+			query:    "bucket=7d&fill=zero",
+			expectedBody: `{"login":"good_user","user_id":"good_user","metric":"view_count_sum","from":"2025-06-04T06:00:00Z","to":"2025-07-04T06:00:00Z",` +
+				`"bucket":"7d","fill":"zero","buckets":[` +
+				`{"start":"2025-06-02T00:00:00Z","snapshot_count":0,"min":0,"max":0,"avg":0},` +
+				`{"start":"2025-06-09T00:00:00Z","snapshot_count":0,"min":0,"max":0,"avg":0},` +
+				`{"start":"2025-06-16T00:00:00Z","snapshot_count":0,"min":0,"max":0,"avg":0},` +
+				`{"start":"2025-06-23T00:00:00Z","snapshot_count":0,"min":0,"max":0,"avg":0},` +
+				`{"start":"2025-06-30T00:00:00Z","snapshot_count":3,"min":100,"max":500,"avg":300}]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:     "Streamer without history",
+			userName: "second_user",
+			expectedBody: problemBody(http.StatusNotFound, handlers.ErrorCodeUserNotFound, "Streamer not found",
+				"no history recorded for streamer second_user", "/ttv-statistics/history/second_user"),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid bucket",
+			userName: "good_user",
+			query:    "bucket=30s",
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter",
+				"bucket must be a number of days such as 1d, or a duration such as 6h, between 1m and 365d", "/ttv-statistics/history/good_user"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:     "From after to",
+			userName: "good_user",
+			query:    "from=2025-07-04T00:00:00Z&to=2025-07-01T00:00:00Z",
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter",
+				"from must be before to", "/ttv-statistics/history/good_user"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Span beyond the range of a duration",
+			userName: "good_user",
+			query:    "from=0001-01-01T00:00:00Z&to=2026-01-01T00:00:00Z&bucket=365d",
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter",
+				"from and to must span at most 1000 buckets", "/ttv-statistics/history/good_user"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Too many buckets",
+			userName: "good_user",
+			query:    "bucket=1m",
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter",
+				"from and to must span at most 1000 buckets", "/ttv-statistics/history/good_user"),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			target := "/ttv-statistics/history/" + tc.userName
+			if tc.query != "" {
+				target += "?" + tc.query
+			}
+
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			req.SetPathValue(handlers.UserNamePathParam, tc.userName)
+
+			rec := httptest.NewRecorder()
+			h.GetStreamerHistory(rec, req)
+
+			resp := rec.Result()
+			defer resp.Body.Close()
+			bodyBytes, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tc.expectedCode {
+				t.Errorf("expected status %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+
+			bodyStr := strings.Trim(string(bodyBytes), "\n")
+
+			if bodyStr != tc.expectedBody {
+				t.Errorf("\nwant %q\n got %q", tc.expectedBody, bodyStr)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"ttv-statistics/constants"
+	"ttv-statistics/helixclient"
+)
+
+const (
+	// maxLiveStreamers is the number of logins helix /streams accepts in one request
+	maxLiveStreamers = 100
+)
+
+type liveStatus struct {
+	Login         string    `json:"login"`
+	UserID        string    `json:"user_id"`
+	DisplayName   string    `json:"display_name"`
+	Live          bool      `json:"live"`
+	ViewerCount   int       `json:"viewer_count"`
+	GameID        string    `json:"game_id"`
+	GameName      string    `json:"game_name"`
+	Title         string    `json:"title"`
+	StartedAt     time.Time `json:"started_at,omitzero"`
+	UptimeSeconds int64     `json:"uptime_seconds"`
+}
+
+type liveStatusResponse struct {
+	Streamers []liveStatus `json:"streamers"`
+	NotFound  []string     `json:"not_found"`
+}
+
+// GetLiveStatus reports whether each of a comma separated list of streamers is live, and describes
+// their stream if so. Streamers are listed in the order requested.
+func (h *Handlers) GetLiveStatus(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	userNames := parseUserNames(r.PathValue(UserNamePathParam))
+	if len(userNames) == 0 {
+		writeProblem(w, r, http.StatusNotFound, ErrorCodeNotFound, fmt.Sprintf("missing required path param %s", UserNamePathParam))
+		return
+	}
+
+	if len(userNames) > maxLiveStreamers {
+		writeProblem(w, r, http.StatusBadRequest, ErrorCodeInvalidParameter,
+			fmt.Sprintf("%s must contain at most %d logins", UserNamePathParam, maxLiveStreamers))
+		return
+	}
+
+	lookups, err := h.helix.LookupUsers(ctx, userNames, nil)
+	if err != nil {
+		writeHelixError(w, r, "error occured obtaining ttv user data", err)
+		return
+	}
+
+	response := liveStatusResponse{
+		Streamers: []liveStatus{},
+		NotFound:  []string{},
+	}
+
+	userIDs := []string{}
+	for _, userName := range userNames {
+		lookup := lookups.ByLogin[userName]
+		if !lookup.Found {
+			response.NotFound = append(response.NotFound, userName)
+			continue
+		}
+		userIDs = append(userIDs, lookup.ID)
+		response.Streamers = append(response.Streamers, liveStatus{
+			Login:       lookup.Login,
+			UserID:      lookup.ID,
+			DisplayName: lookup.DisplayName,
+		})
+	}
+
+	// a single unknown streamer is a missing resource, as for the statistics endpoints
+	if len(userNames) == 1 && len(response.NotFound) == 1 {
+		writeProblem(w, r, http.StatusNotFound, ErrorCodeUserNotFound, fmt.Sprintf("no twitch user found with login %s", userNames[0]))
+		return
+	}
+
+	streamsData, err := h.helix.GetStreams(ctx, userIDs)
+	if err != nil {
+		writeHelixError(w, r, "error occured obtaining ttv stream data", err)
+		return
+	}
+
+	streams := map[string]helixclient.StreamInfo{}
+	for _, stream := range streamsData.Data {
+		streams[stream.UserID] = stream
+	}
+
+	now := h.now()
+
+	for i, status := range response.Streamers {
+		stream, ok := streams[status.UserID]
+		if !ok {
+			continue
+		}
+		response.Streamers[i].Live = true
+		response.Streamers[i].ViewerCount = stream.ViewerCount
+		response.Streamers[i].GameID = stream.GameID
+		response.Streamers[i].GameName = stream.GameName
+		response.Streamers[i].Title = stream.Title
+		response.Streamers[i].StartedAt = stream.StartedAt
+		response.Streamers[i].UptimeSeconds = int64(max(now.Sub(stream.StartedAt), 0).Seconds())
+	}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		writeInternalError(w, r, "failed to marshal response body", err)
+		return
+	}
+
+	w.Header().Set(constants.ContentTypeHeaderKey, constants.ContentTypeApplicationJson)
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+}
@@ -0,0 +1,104 @@
+package handlers_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"ttv-statistics/handlers"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/testutil"
+)
+
+func TestGetLiveStatus(t *testing.T) {
+
+	stubServer := httptest.NewServer(testutil.StubServerMux())
+	defer stubServer.Close()
+
+	now := testutil.LiveStreamStartedAt.Add(time.Hour + time.Minute*30)
+
+	h := handlers.NewHandlers(
+		helixclient.NewClient(helixclient.WithHelixHost(stubServer.URL)),
+		handlers.WithClock(func() time.Time { return now }),
+	)
+
+	const (
+		liveGoodUser      = `{"login":"good_user","user_id":"good_user","display_name":"Streamer A","live":true,"viewer_count":1234,"game_id":"509658","game_name":"Just Chatting","title":"Sample Stream","started_at":"2025-06-04T12:00:00Z","uptime_seconds":5400}`
+		offlineSecondUser = `{"login":"second_user","user_id":"second_user","display_name":"Streamer B","live":false,"viewer_count":0,"game_id":"","game_name":"","title":"","uptime_seconds":0}`
+	)
+
+	tooManyUserNames := []string{}
+	for i := range 101 {
+		tooManyUserNames = append(tooManyUserNames, fmt.Sprintf("%s%d", testutil.BulkUserPrefix, i))
+	}
+
+	type testCase struct {
+		name         string
+		userNames    string
+		expectedBody string
+		expectedCode int
+	}
+
+	testCases := []testCase{
+		{
+			name:         "Live streamer",
+			userNames:    "good_user",
+			expectedBody: `{"streamers":[` + liveGoodUser + `],"not_found":[]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Offline streamer",
+			userNames:    testutil.SecondUserName,
+			expectedBody: `{"streamers":[` + offlineSecondUser + `],"not_found":[]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Several streamers in the order requested",
+			userNames:    "Second_User,good_user,unknown_user,good_user",
+			expectedBody: `{"streamers":[` + offlineSecondUser + `,` + liveGoodUser + `],"not_found":["unknown_user"]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:      "Single unknown streamer",
+			userNames: "unknown_user",
+			expectedBody: problemBody(http.StatusNotFound, handlers.ErrorCodeUserNotFound, "Streamer not found",
+				"no twitch user found with login unknown_user", "/ttv-statistics/live/unknown_user"),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:      "Too many streamers",
+			userNames: strings.Join(tooManyUserNames, ","),
+			expectedBody: problemBody(http.StatusBadRequest, handlers.ErrorCodeInvalidParameter, "Invalid parameter",
+				"username must contain at most 100 logins", "/ttv-statistics/live/"+strings.Join(tooManyUserNames, ",")),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/ttv-statistics/live/%s", tc.userNames), nil)
+			req.SetPathValue(handlers.UserNamePathParam, tc.userNames)
+
+			rec := httptest.NewRecorder()
+			h.GetLiveStatus(rec, req)
+
+			resp := rec.Result()
+			defer resp.Body.Close()
+			bodyBytes, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tc.expectedCode {
+				t.Errorf("expected status %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+
+			bodyStr := strings.Trim(string(bodyBytes), "\n")
+
+			if bodyStr != tc.expectedBody {
+				t.Errorf("\nwant %q\n got %q", tc.expectedBody, bodyStr)
+			}
+		})
+	}
+}
@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"ttv-statistics/constants"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/openapi"
+	"ttv-statistics/statstools"
+)
+
+var (
+	// schemaOverrides describes the types with a custom JSON encoding, or values worth enumerating
+	schemaOverrides = map[reflect.Type]*openapi.Schema{
+		reflect.TypeFor[statstools.Duration](): {
+			Description: "Sum of the video lengths, encoded as chosen by duration_format",
+			OneOf: []*openapi.Schema{
+				{Type: openapi.TypeInteger, Description: "Nanoseconds, the ns format"},
+				{Type: openapi.TypeNumber, Description: "Seconds, the seconds format"},
+				{Type: openapi.TypeString, Description: "An ISO 8601 duration or a Go duration, the iso8601 and go formats"},
+			},
+		},
+		reflect.TypeFor[statstools.ComparisonMetric](): {
+			Type: openapi.TypeString,
+			Enum: toStrings(statstools.ComparisonMetrics),
+		},
+		reflect.TypeFor[statstools.HistoryMetric](): {
+			Type: openapi.TypeString,
+			Enum: toStrings(statstools.HistoryMetrics),
+		},
+		reflect.TypeFor[statstools.GapFill](): {
+			Type: openapi.TypeString,
+			Enum: toStrings(statstools.GapFills),
+		},
+		reflect.TypeFor[helixclient.CircuitBreakerState](): {
+			Type: openapi.TypeString,
+			Enum: toStrings([]helixclient.CircuitBreakerState{helixclient.CircuitBreakerClosed, helixclient.CircuitBreakerOpen, helixclient.CircuitBreakerHalfOpen}),
+		},
+		reflect.TypeFor[ErrorCode](): {
+			Type: openapi.TypeString,
+			Enum: errorCodes(),
+		},
+	}
+
+	userNameParameter = openapi.Parameter{
+		Name:     UserNamePathParam,
+		In:       openapi.InPath,
+		Required: true,
+		Schema:   &openapi.Schema{Type: openapi.TypeString},
+	}
+
+	filterParameters = []openapi.Parameter{
+		{
+			Name:        VideoType,
+			In:          openapi.InQuery,
+			Description: "Type of video to include",
+			Schema:      &openapi.Schema{Type: openapi.TypeString, Enum: helixclient.VideoTypes},
+		},
+		{
+			Name:        VideoPeriod,
+			In:          openapi.InQuery,
+			Description: "Period the videos were published in",
+			Schema:      &openapi.Schema{Type: openapi.TypeString, Enum: helixclient.VideoPeriods},
+		},
+		{
+			Name:        VideoSort,
+			In:          openapi.InQuery,
+			Description: "Order in which videos are selected",
+			Schema:      &openapi.Schema{Type: openapi.TypeString, Enum: helixclient.VideoSorts},
+		},
+	}
+)
+
+// GetStreamerVideoStatisticsOperation describes GetStreamerVideoStatistics.
+func GetStreamerVideoStatisticsOperation() openapi.Operation {
+	return openapi.Operation{
+		OperationID: "getStreamerVideoStatistics",
+		Summary:     "Aggregate statistics of a streamer's videos",
+		Parameters: slices.Concat([]openapi.Parameter{
+			userNameParameter,
+			{
+				Name:        LastN,
+				In:          openapi.InQuery,
+				Description: "Number of most recent videos to include. Required unless since or until is provided, in which case it caps the number of videos",
+				Schema:      &openapi.Schema{Type: openapi.TypeInteger, Minimum: openapi.Int(1)},
+			},
+			{
+				Name:        Since,
+				In:          openapi.InQuery,
+				Description: "Only videos created at or after this time are included",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Format: openapi.FormatDateTime},
+			},
+			{
+				Name:        Until,
+				In:          openapi.InQuery,
+				Description: "Only videos created at or before this time are included",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Format: openapi.FormatDateTime},
+			},
+			{
+				Name:        DurationFormat,
+				In:          openapi.InQuery,
+				Description: "Encoding of video_lengths_sum, ns when omitted",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Enum: toStrings(statstools.DurationFormats)},
+			},
+			{
+				Name:        Precision,
+				In:          openapi.InQuery,
+				Description: "Number of decimal places to round floating point statistics to, unrounded when omitted",
+				Schema:      &openapi.Schema{Type: openapi.TypeInteger, Minimum: openapi.Int(0), Maximum: openapi.Int(statstools.MaxPrecision)},
+			},
+		}, filterParameters),
+		Responses: withProblemResponses(map[string]openapi.Response{
+			strconv.Itoa(http.StatusOK): jsonResponse("Statistics of the selected videos", statstools.LastNVideoStatistics{}),
+		}),
+	}
+}
+
+// CompareStreamersOperation describes CompareStreamers.
+func CompareStreamersOperation() openapi.Operation {
+	return openapi.Operation{
+		OperationID: "compareStreamers",
+		Summary:     "Rank streamers by the statistics of their videos",
+		Parameters: slices.Concat([]openapi.Parameter{
+			{
+				Name:        Users,
+				In:          openapi.InQuery,
+				Description: "Comma separated list of up to 25 logins, case insensitive",
+				Required:    true,
+				Schema:      &openapi.Schema{Type: openapi.TypeString},
+			},
+			{
+				Name:        LastN,
+				In:          openapi.InQuery,
+				Description: "Number of most recent videos to include for each streamer",
+				Required:    true,
+				Schema:      &openapi.Schema{Type: openapi.TypeInteger, Minimum: openapi.Int(1), Maximum: openapi.Int(maxComparedVideos)},
+			},
+			{
+				Name:        RankBy,
+				In:          openapi.InQuery,
+				Description: "Metric to rank by, view_count_avg when omitted",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Enum: toStrings(statstools.ComparisonMetrics)},
+			},
+		}, filterParameters),
+		Responses: withProblemResponses(map[string]openapi.Response{
+			strconv.Itoa(http.StatusOK): jsonResponse("The streamers ranked side by side", compareStreamersResponse{}),
+		}),
+	}
+}
+
+// GetStreamerClipStatisticsOperation describes GetStreamerClipStatistics.
+func GetStreamerClipStatisticsOperation() openapi.Operation {
+	return openapi.Operation{
+		OperationID: "getStreamerClipStatistics",
+		Summary:     "Aggregate statistics of the clips made of a streamer's broadcasts",
+		Parameters: []openapi.Parameter{
+			userNameParameter,
+			{
+				Name:        LastN,
+				In:          openapi.InQuery,
+				Description: "Number of most viewed clips to include",
+				Schema:      &openapi.Schema{Type: openapi.TypeInteger, Minimum: openapi.Int(1), Maximum: openapi.Int(maxClipCount)},
+			},
+			{
+				Name:        Top,
+				In:          openapi.InQuery,
+				Description: "Number of top clips and top clippers to report",
+				Schema:      &openapi.Schema{Type: openapi.TypeInteger, Minimum: openapi.Int(1), Maximum: openapi.Int(maxTopCount)},
+			},
+			{
+				Name:        Since,
+				In:          openapi.InQuery,
+				Description: "Only clips created at or after this time are included",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Format: openapi.FormatDateTime},
+			},
+			{
+				Name:        Until,
+				In:          openapi.InQuery,
+				Description: "Only clips created at or before this time are included, requires since",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Format: openapi.FormatDateTime},
+			},
+		},
+		Responses: withProblemResponses(map[string]openapi.Response{
+			strconv.Itoa(http.StatusOK): jsonResponse("Statistics of the selected clips", statstools.ClipStatistics{}),
+		}),
+	}
+}
+
+// GetLiveStatusOperation describes GetLiveStatus.
+func GetLiveStatusOperation() openapi.Operation {
+	return openapi.Operation{
+		OperationID: "getLiveStatus",
+		Summary:     "Report whether streamers are live",
+		Description: "A single unknown login responds 404, unknown logins among several are listed in not_found.",
+		Parameters: []openapi.Parameter{
+			{
+				Name:        UserNamePathParam,
+				In:          openapi.InPath,
+				Description: "Comma separated list of up to 100 logins, case insensitive",
+				Required:    true,
+				Schema:      &openapi.Schema{Type: openapi.TypeString},
+			},
+		},
+		Responses: withProblemResponses(map[string]openapi.Response{
+			strconv.Itoa(http.StatusOK): jsonResponse("The live status of each streamer, in the order requested", liveStatusResponse{}),
+		}),
+	}
+}
+
+// GetStreamerHistoryOperation describes GetStreamerHistory.
+func GetStreamerHistoryOperation() openapi.Operation {
+	return openapi.Operation{
+		OperationID: "getStreamerHistory",
+		Summary:     "A statistic of a streamer's snapshots over time",
+		Description: "Only streamers on the watchlist of the snapshot collector have a history, others respond 404.",
+		Parameters: []openapi.Parameter{
+			userNameParameter,
+			{
+				Name:        Metric,
+				In:          openapi.InQuery,
+				Description: "Statistic to report, by its path in the video statistics, view_count_sum when omitted",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Enum: toStrings(statstools.HistoryMetrics)},
+			},
+			{
+				Name:        From,
+				In:          openapi.InQuery,
+				Description: "Only snapshots collected at or after this time are included, 30 days before to when omitted",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Format: openapi.FormatDateTime},
+			},
+			{
+				Name:        To,
+				In:          openapi.InQuery,
+				Description: "Only snapshots collected before this time are included, now when omitted",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Format: openapi.FormatDateTime},
+			},
+			{
+				Name:        Bucket,
+				In:          openapi.InQuery,
+				Description: "Length of each bucket, a number of days such as 7d or a duration such as 6h, 1d when omitted",
+				Schema:      &openapi.Schema{Type: openapi.TypeString},
+			},
+			{
+				Name:        Fill,
+				In:          openapi.InQuery,
+				Description: "Values of buckets without snapshots, null when omitted",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Enum: toStrings(statstools.GapFills)},
+			},
+		},
+		Responses: withProblemResponses(map[string]openapi.Response{
+			strconv.Itoa(http.StatusOK): jsonResponse("The statistic summarised over each bucket, oldest first", historyResponse{}),
+		}),
+	}
+}
+
+// HealthOperation describes Health.
+func HealthOperation() openapi.Operation {
+	return openapi.Operation{
+		OperationID: "health",
+		Summary:     "Report whether requests are reaching Twitch",
+		Responses: map[string]openapi.Response{
+			strconv.Itoa(http.StatusOK): jsonResponse("The state of the helix circuit breaker and the helix cache statistics", healthResponse{}),
+		},
+	}
+}
+
+// OpenAPIDocumentOperation describes the handler returned by OpenAPIDocument.
+func OpenAPIDocumentOperation() openapi.Operation {
+	return openapi.Operation{
+		OperationID: "openAPIDocument",
+		Summary:     "This OpenAPI document",
+		Responses: map[string]openapi.Response{
+			strconv.Itoa(http.StatusOK): {
+				Description: "The OpenAPI document describing the API",
+				Content: map[string]openapi.MediaType{
+					constants.ContentTypeApplicationJson: {Schema: &openapi.Schema{Type: openapi.TypeObject}},
+				},
+			},
+		},
+	}
+}
+
+// OpenAPIDocument serves the document returned by document, which is called once on the first request
+// so the document may describe the endpoint serving it.
+func OpenAPIDocument(document func() openapi.Document) http.HandlerFunc {
+
+	marshal := sync.OnceValues(func() ([]byte, error) {
+		return json.Marshal(document())
+	})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		payload, err := marshal()
+		if err != nil {
+			writeInternalError(w, r, "failed to marshal openapi document", err)
+			return
+		}
+
+		w.Header().Set(constants.ContentTypeHeaderKey, constants.ContentTypeApplicationJson)
+		w.WriteHeader(http.StatusOK)
+		w.Write(payload)
+	}
+}
+
+// ValidateParameters rejects requests whose path or query parameters do not satisfy operation, before
+// they reach next, with the same problems the handlers report.
+func ValidateParameters(operation openapi.Operation, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if err := operation.ValidateRequest(r); err != nil {
+			var parameterErr *openapi.ParameterError
+			if errors.As(err, &parameterErr) && errors.Is(err, openapi.ErrMissingParameter) {
+				writeMissingParameter(w, r, parameterErr.Name)
+				return
+			}
+			writeInvalidParameter(w, r, err)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func jsonResponse(description string, body any) openapi.Response {
+	return openapi.Response{
+		Description: description,
+		Content: map[string]openapi.MediaType{
+			constants.ContentTypeApplicationJson: {Schema: openapi.SchemaOf(reflect.TypeOf(body), schemaOverrides)},
+		},
+	}
+}
+
+// withProblemResponses adds the problem responses every endpoint calling helix may return
+func withProblemResponses(responses map[string]openapi.Response) map[string]openapi.Response {
+
+	problem := map[string]openapi.MediaType{
+		constants.ContentTypeProblemJson: {Schema: openapi.SchemaOf(reflect.TypeFor[Problem](), schemaOverrides)},
+	}
+	retryAfter := map[string]openapi.Header{
+		constants.RetryAfterHeaderKey: {
+			Description: "Seconds until Twitch is expected to accept requests again",
+			Schema:      &openapi.Schema{Type: openapi.TypeInteger},
+		},
+	}
+
+	for status, description := range map[int]string{
+		http.StatusBadRequest:          "A parameter is missing or invalid, or Twitch rejected the request",
+		http.StatusNotFound:            "The streamer or path was not found",
+		http.StatusInternalServerError: "An unexpected fault in this service",
+		http.StatusBadGateway:          "Twitch failed or rejected the service's credentials",
+		http.StatusGatewayTimeout:      "Twitch did not respond in time",
+		StatusClientClosedRequest:      "The client disconnected before the response was written",
+	} {
+		responses[strconv.Itoa(status)] = openapi.Response{Description: description, Content: problem}
+	}
+
+	responses[strconv.Itoa(http.StatusServiceUnavailable)] = openapi.Response{
+		Description: "Twitch is rate limiting the service or the circuit breaker is open",
+		Headers:     retryAfter,
+		Content:     problem,
+	}
+
+	return responses
+}
+
+func errorCodes() []string {
+
+	codes := make([]string, 0, len(problemTitles))
+	for code := range problemTitles {
+		codes = append(codes, string(code))
+	}
+	slices.Sort(codes)
+
+	return codes
+}
+
+func toStrings[S ~string](values []S) []string {
+
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = string(value)
+	}
+
+	return strs
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"ttv-statistics/constants"
+	"ttv-statistics/helixclient"
+)
+
+// ErrorCode is a machine readable identifier of the kind of problem an error response describes.
+type ErrorCode string
+
+const (
+	ErrorCodeMissingParameter     ErrorCode = "missing_parameter"
+	ErrorCodeInvalidParameter     ErrorCode = "invalid_parameter"
+	ErrorCodeNotFound             ErrorCode = "not_found"
+	ErrorCodeUserNotFound         ErrorCode = "user_not_found"
+	ErrorCodeUpstreamBadRequest   ErrorCode = "upstream_bad_request"
+	ErrorCodeUpstreamNotFound     ErrorCode = "upstream_not_found"
+	ErrorCodeUpstreamUnauthorised ErrorCode = "upstream_unauthorised"
+	ErrorCodeUpstreamRateLimited  ErrorCode = "upstream_rate_limited"
+	ErrorCodeUpstreamUnavailable  ErrorCode = "upstream_unavailable"
+	ErrorCodeUpstreamError        ErrorCode = "upstream_error"
+	ErrorCodeUpstreamTimeout      ErrorCode = "upstream_timeout"
+	ErrorCodeClientClosedRequest  ErrorCode = "client_closed_request"
+	ErrorCodeInternal             ErrorCode = "internal_error"
+
+	problemTypePrefix = "urn:ttv-statistics:problem:"
+
+	// StatusClientClosedRequest is the non-standard status, popularised by nginx, recorded for requests
+	// the client abandoned before a response was written
+	StatusClientClosedRequest = 499
+)
+
+var (
+	problemTitles = map[ErrorCode]string{
+		ErrorCodeMissingParameter:     "Missing required parameter",
+		ErrorCodeInvalidParameter:     "Invalid parameter",
+		ErrorCodeNotFound:             "Not found",
+		ErrorCodeUserNotFound:         "Streamer not found",
+		ErrorCodeUpstreamBadRequest:   "Twitch rejected the request",
+		ErrorCodeUpstreamNotFound:     "Not found on Twitch",
+		ErrorCodeUpstreamUnauthorised: "Twitch rejected the service credentials",
+		ErrorCodeUpstreamRateLimited:  "Twitch rate limit exceeded",
+		ErrorCodeUpstreamUnavailable:  "Twitch is unavailable",
+		ErrorCodeUpstreamError:        "Twitch request failed",
+		ErrorCodeUpstreamTimeout:      "Twitch request timed out",
+		ErrorCodeClientClosedRequest:  "Client closed request",
+		ErrorCodeInternal:             "Internal server error",
+	}
+)
+
+// Problem is an RFC 7807 problem details error response body. Code repeats the last segment of Type so
+// clients can switch on it without parsing the URI.
+type Problem struct {
+	Type     string    `json:"type"`
+	Title    string    `json:"title"`
+	Status   int       `json:"status"`
+	Detail   string    `json:"detail"`
+	Instance string    `json:"instance"`
+	Code     ErrorCode `json:"code"`
+}
+
+// writeProblem is the single place handlers write error responses, as application/problem+json.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code ErrorCode, detail string) {
+
+	problem := Problem{
+		Type:     problemTypePrefix + string(code),
+		Title:    problemTitles[code],
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+	}
+
+	// details quote upstream URLs, which are easier to read without HTML escaping of their query strings
+	var payload bytes.Buffer
+	encoder := json.NewEncoder(&payload)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(problem); err != nil {
+		log.Printf("message=%s error=%v", "failed to marshal problem", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(constants.ContentTypeHeaderKey, constants.ContentTypeProblemJson)
+	w.WriteHeader(status)
+	w.Write(payload.Bytes())
+}
+
+func writeMissingParameter(w http.ResponseWriter, r *http.Request, parameter string) {
+	writeProblem(w, r, http.StatusBadRequest, ErrorCodeMissingParameter, fmt.Sprintf("missing required URL param %s", parameter))
+}
+
+func writeInvalidParameter(w http.ResponseWriter, r *http.Request, err error) {
+	writeProblem(w, r, http.StatusBadRequest, ErrorCodeInvalidParameter, err.Error())
+}
+
+func writeInternalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	writeProblem(w, r, http.StatusInternalServerError, ErrorCodeInternal, fmt.Sprintf("%s: %v", message, err))
+}
+
+// writeHelixError reports a failed helix request with a status reflecting why it failed:
+//   - an open circuit breaker or helix rate limiting is 503 Service Unavailable with a Retry-After, so
+//     clients back off rather than retrying immediately
+//   - helix rejecting the request as malformed or not found is passed through as 400 or 404
+//   - helix rejecting our credentials or failing itself is 502 Bad Gateway, as the client is not at fault,
+//     including the OAuth server refusing to issue an access token for them
+//   - helix not responding in time is 504 Gateway Timeout
+//   - the client disconnecting first is 499, so it is not mistaken for a fault in this service
+//
+// Anything else is a 500 Internal Server Error.
+func writeHelixError(w http.ResponseWriter, r *http.Request, message string, err error) {
+
+	var (
+		circuitErr   *helixclient.CircuitOpenError
+		authErr      *helixclient.AuthError
+		apiErr       *helixclient.APIError
+		transportErr *helixclient.TransportError
+	)
+
+	detail := fmt.Sprintf("%s: %v", message, err)
+
+	switch {
+	case errors.Is(err, context.Canceled) && r.Context().Err() != nil:
+		writeProblem(w, r, StatusClientClosedRequest, ErrorCodeClientClosedRequest, detail)
+	case errors.As(err, &circuitErr):
+		writeRetryAfter(w, circuitErr.RetryAfter)
+		writeProblem(w, r, http.StatusServiceUnavailable, ErrorCodeUpstreamUnavailable, detail)
+	case errors.As(err, &apiErr):
+		status, code := helixProblem(apiErr.StatusCode)
+		// the OAuth server refusing a token, e.g. 400 for an invalid client, is our configuration at fault
+		if errors.As(err, &authErr) && apiErr.StatusCode < http.StatusInternalServerError && apiErr.StatusCode != http.StatusTooManyRequests {
+			status, code = http.StatusBadGateway, ErrorCodeUpstreamUnauthorised
+		}
+		if code == ErrorCodeUpstreamUnauthorised {
+			log.Printf("message=%s url=%s status_code=%d body=%q", "helix rejected credentials", apiErr.URL, apiErr.StatusCode, apiErr.Body)
+		}
+		if status == http.StatusServiceUnavailable {
+			writeRetryAfter(w, apiErr.RetryAfter)
+		}
+		writeProblem(w, r, status, code, detail)
+	case errors.As(err, &transportErr) && transportErr.Timeout(), errors.Is(err, context.DeadlineExceeded):
+		writeProblem(w, r, http.StatusGatewayTimeout, ErrorCodeUpstreamTimeout, detail)
+	case errors.As(err, &transportErr):
+		writeProblem(w, r, http.StatusBadGateway, ErrorCodeUpstreamError, detail)
+	default:
+		writeProblem(w, r, http.StatusInternalServerError, ErrorCodeInternal, detail)
+	}
+}
+
+// helixProblem maps a helix response status to the status and error code reported to our clients
+func helixProblem(helixStatusCode int) (int, ErrorCode) {
+
+	switch helixStatusCode {
+	case http.StatusBadRequest:
+		return http.StatusBadRequest, ErrorCodeUpstreamBadRequest
+	case http.StatusNotFound:
+		return http.StatusNotFound, ErrorCodeUpstreamNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return http.StatusBadGateway, ErrorCodeUpstreamUnauthorised
+	case http.StatusTooManyRequests:
+		return http.StatusServiceUnavailable, ErrorCodeUpstreamRateLimited
+	case http.StatusGatewayTimeout:
+		return http.StatusGatewayTimeout, ErrorCodeUpstreamTimeout
+	default:
+		return http.StatusBadGateway, ErrorCodeUpstreamError
+	}
+}
+
+func writeRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	if retryAfter > 0 {
+		w.Header().Set(constants.RetryAfterHeaderKey, strconv.Itoa(max(int(math.Ceil(retryAfter.Seconds())), 1)))
+	}
+}
@@ -0,0 +1,279 @@
+package helixclient
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type CircuitBreakerState string
+
+const (
+	CircuitBreakerClosed   CircuitBreakerState = "closed"
+	CircuitBreakerOpen     CircuitBreakerState = "open"
+	CircuitBreakerHalfOpen CircuitBreakerState = "half_open"
+
+	DefaultCircuitBreakerWindowSize          int           = 20
+	DefaultCircuitBreakerMinRequests         int           = 10
+	DefaultCircuitBreakerFailureRate         float64       = 0.5
+	DefaultCircuitBreakerOpenDuration        time.Duration = time.Second * 30
+	DefaultCircuitBreakerHalfOpenMaxRequests int           = 1
+
+	// minProbeRetryAfter is the least a request turned away by probes in flight is told to wait
+	minProbeRetryAfter time.Duration = time.Second
+)
+
+// ErrCircuitOpen matches, with errors.Is, the error returned without contacting helix while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("helix circuit breaker is open")
+
+// CircuitOpenError is returned while the circuit breaker is open, reporting when a probe request will
+// next be let through. Use errors.As to obtain it.
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("message=%s retry_after=%s", ErrCircuitOpen, e.RetryAfter)
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitBreakerPolicy configures when the circuit breaker opens and how it recovers.
+type CircuitBreakerPolicy struct {
+	// WindowSize is the number of most recent helix requests the failure rate is measured over
+	WindowSize int
+	// MinRequests is the number of requests the window must hold before the breaker can open
+	MinRequests int
+	// FailureRate is the fraction of failed requests in the window, between 0 and 1, that opens the
+	// breaker, 0 disables the breaker
+	FailureRate float64
+	// OpenDuration is how long the breaker fails fast before letting probe requests through
+	OpenDuration time.Duration
+	// HalfOpenMaxRequests is the number of concurrent probe requests allowed while half open, all of
+	// which must succeed for the breaker to close
+	HalfOpenMaxRequests int
+}
+
+func DefaultCircuitBreakerPolicy() CircuitBreakerPolicy {
+	return CircuitBreakerPolicy{
+		WindowSize:          DefaultCircuitBreakerWindowSize,
+		MinRequests:         DefaultCircuitBreakerMinRequests,
+		FailureRate:         DefaultCircuitBreakerFailureRate,
+		OpenDuration:        DefaultCircuitBreakerOpenDuration,
+		HalfOpenMaxRequests: DefaultCircuitBreakerHalfOpenMaxRequests,
+	}
+}
+
+func WithCircuitBreakerPolicy(policy CircuitBreakerPolicy) Option {
+	return func(c *Client) {
+		c.circuitBreakerPolicy = policy
+	}
+}
+
+// circuitBreaker stops requests reaching a degraded helix. It opens once the failure rate over the last
+// WindowSize requests reaches FailureRate, fails fast for OpenDuration, then lets HalfOpenMaxRequests
+// probes through; the breaker closes if they all succeed and opens again if any fails.
+type circuitBreaker struct {
+	mu     sync.Mutex
+	policy CircuitBreakerPolicy
+	now    func() time.Time
+
+	state    CircuitBreakerState
+	openedAt time.Time
+
+	// outcomes is a ring buffer of the most recent results while closed, true marking a failure
+	outcomes []bool
+	next     int
+	recorded int
+	failures int
+
+	probes         int
+	probeSuccesses int
+}
+
+func newCircuitBreaker(policy CircuitBreakerPolicy, now func() time.Time) *circuitBreaker {
+	return &circuitBreaker{
+		policy:   policy,
+		now:      now,
+		state:    CircuitBreakerClosed,
+		outcomes: make([]bool, max(policy.WindowSize, 1)),
+	}
+}
+
+func (b *circuitBreaker) enabled() bool {
+	return b.policy.FailureRate > 0
+}
+
+func (b *circuitBreaker) currentState() CircuitBreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitBreakerOpen && !b.now().Before(b.openedAt.Add(b.policy.OpenDuration)) {
+		return CircuitBreakerHalfOpen
+	}
+
+	return b.state
+}
+
+// allow reports whether a request may be sent, returning a *CircuitOpenError if not. probe reports whether
+// the request is a half open probe, which must be passed back to record.
+func (b *circuitBreaker) allow() (probe bool, err error) {
+
+	if !b.enabled() {
+		return false, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitBreakerOpen {
+		if now := b.now(); now.Before(b.openedAt.Add(b.policy.OpenDuration)) {
+			return false, &CircuitOpenError{RetryAfter: b.openedAt.Add(b.policy.OpenDuration).Sub(now)}
+		}
+		b.transition(CircuitBreakerHalfOpen)
+	}
+
+	if b.state == CircuitBreakerHalfOpen {
+		if b.probes >= max(b.policy.HalfOpenMaxRequests, 1) {
+			// the probes in flight decide the state shortly, so clients are asked to back off for a
+			// fraction of the open duration rather than the whole of it
+			return false, &CircuitOpenError{RetryAfter: b.probeRetryAfter()}
+		}
+		b.probes++
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// rejectIfOpen returns a *CircuitOpenError while the breaker is open, like allow, but without claiming a
+// half open probe, so requests can fail fast before waiting for the rate limit.
+func (b *circuitBreaker) rejectIfOpen() error {
+
+	if !b.enabled() {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitBreakerOpen {
+		if now := b.now(); now.Before(b.openedAt.Add(b.policy.OpenDuration)) {
+			return &CircuitOpenError{RetryAfter: b.openedAt.Add(b.policy.OpenDuration).Sub(now)}
+		}
+	}
+
+	return nil
+}
+
+// probeRetryAfter is how long a request turned away while probes are in flight should wait
+func (b *circuitBreaker) probeRetryAfter() time.Duration {
+	return max(b.policy.OpenDuration/10, minProbeRetryAfter)
+}
+
+// record reports the outcome of a request let through by allow.
+func (b *circuitBreaker) record(probe bool, failed bool) {
+
+	if !b.enabled() {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		if b.state != CircuitBreakerHalfOpen {
+			return
+		}
+
+		if failed {
+			b.transition(CircuitBreakerOpen)
+			return
+		}
+
+		b.probeSuccesses++
+		if b.probeSuccesses >= max(b.policy.HalfOpenMaxRequests, 1) {
+			b.transition(CircuitBreakerClosed)
+		}
+		return
+	}
+
+	if b.state != CircuitBreakerClosed {
+		// results of requests sent before the breaker opened say nothing about its probes
+		return
+	}
+
+	if b.recorded == len(b.outcomes) && b.outcomes[b.next] {
+		b.failures--
+	}
+
+	b.outcomes[b.next] = failed
+	b.next = (b.next + 1) % len(b.outcomes)
+	b.recorded = min(b.recorded+1, len(b.outcomes))
+
+	if failed {
+		b.failures++
+	}
+
+	if b.recorded >= min(b.policy.MinRequests, len(b.outcomes)) && float64(b.failures)/float64(b.recorded) >= b.policy.FailureRate {
+		b.transition(CircuitBreakerOpen)
+	}
+}
+
+// abandon releases a probe whose request was cancelled by the caller, so its outcome is unknown.
+func (b *circuitBreaker) abandon(probe bool) {
+
+	if !probe {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitBreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// transition expects b.mu to be held
+func (b *circuitBreaker) transition(state CircuitBreakerState) {
+
+	log.Printf("message=%s from=%s to=%s", "helix circuit breaker state changed", b.state, state)
+
+	b.state = state
+	b.probes = 0
+	b.probeSuccesses = 0
+
+	switch state {
+	case CircuitBreakerOpen:
+		b.openedAt = b.now()
+	case CircuitBreakerClosed:
+		clear(b.outcomes)
+		b.next = 0
+		b.recorded = 0
+		b.failures = 0
+	}
+}
+
+// isUpstreamFailure reports whether err suggests helix is degraded: a transport error, a timeout or a
+// 5xx response. Client errors, rate limiting and cancellation by the caller do not count against helix.
+func isUpstreamFailure(err error) bool {
+
+	if err == nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var transportErr *TransportError
+	return errors.As(err, &transportErr)
+}
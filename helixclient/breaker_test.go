@@ -0,0 +1,277 @@
+package helixclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/testutil"
+)
+
+// newToggleStubServer serves the stub helix API, failing every /users request with 503 Service
+// Unavailable while failing is set.
+func newToggleStubServer(counter *atomic.Int32, failing *atomic.Bool) *httptest.Server {
+	mux := testutil.StubServerMux()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == helixclient.HelixUsersEndpoint {
+			counter.Add(1)
+			if failing.Load() {
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	policy := helixclient.CircuitBreakerPolicy{
+		WindowSize:          4,
+		MinRequests:         4,
+		FailureRate:         0.5,
+		OpenDuration:        time.Second * 30,
+		HalfOpenMaxRequests: 1,
+	}
+
+	type testCase struct {
+		name             string
+		failingAfterOpen bool
+		elapsed          time.Duration
+		expectError      bool
+		expectedState    helixclient.CircuitBreakerState
+		expectedRequests int32
+	}
+
+	testCases := []testCase{
+		{
+			name:             "Open breaker fails fast",
+			failingAfterOpen: false,
+			elapsed:          time.Second * 10,
+			expectError:      true,
+			expectedState:    helixclient.CircuitBreakerOpen,
+			expectedRequests: 4,
+		},
+		{
+			name:             "Successful probe closes the breaker",
+			failingAfterOpen: false,
+			elapsed:          time.Second * 30,
+			expectError:      false,
+			expectedState:    helixclient.CircuitBreakerClosed,
+			expectedRequests: 5,
+		},
+		{
+			name:             "Failed probe opens the breaker again",
+			failingAfterOpen: true,
+			elapsed:          time.Second * 30,
+			expectError:      true,
+			expectedState:    helixclient.CircuitBreakerOpen,
+			expectedRequests: 5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var userRequests atomic.Int32
+			var failing atomic.Bool
+			server := newToggleStubServer(&userRequests, &failing)
+			defer server.Close()
+
+			now := time.Now()
+			client := helixclient.NewClient(
+				helixclient.WithHelixHost(server.URL),
+				helixclient.WithClock(func() time.Time { return now }),
+				helixclient.WithRetryPolicy(helixclient.RetryPolicy{MaxAttempts: 1}),
+				helixclient.WithCircuitBreakerPolicy(policy),
+			)
+
+			// two successes then two failures reach the failure rate once the window holds enough requests
+			for i := range 4 {
+				failing.Store(i >= 2)
+				client.GetUserData(context.Background(), "good_user")
+			}
+
+			if got := client.CircuitBreakerState(); got != helixclient.CircuitBreakerOpen {
+				t.Fatalf("expected breaker to be %s, got %s", helixclient.CircuitBreakerOpen, got)
+			}
+
+			failing.Store(tc.failingAfterOpen)
+			now = now.Add(tc.elapsed)
+
+			_, err := client.GetUserData(context.Background(), "good_user")
+			if tc.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			var circuitErr *helixclient.CircuitOpenError
+			if tc.elapsed < policy.OpenDuration {
+				if !errors.Is(err, helixclient.ErrCircuitOpen) || !errors.As(err, &circuitErr) {
+					t.Fatalf("expected circuit open error, got %v", err)
+				}
+				if expected := policy.OpenDuration - tc.elapsed; circuitErr.RetryAfter != expected {
+					t.Errorf("expected retry after %s, got %s", expected, circuitErr.RetryAfter)
+				}
+			}
+
+			if got := client.CircuitBreakerState(); got != tc.expectedState {
+				t.Errorf("expected breaker to be %s, got %s", tc.expectedState, got)
+			}
+			if got := userRequests.Load(); got != tc.expectedRequests {
+				t.Errorf("expected %d user requests, got %d", tc.expectedRequests, got)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(testutil.StubServerMux())
+	defer server.Close()
+
+	client := helixclient.NewClient(
+		helixclient.WithHelixHost(server.URL),
+		helixclient.WithCircuitBreakerPolicy(helixclient.CircuitBreakerPolicy{
+			WindowSize:  2,
+			MinRequests: 2,
+			FailureRate: 0.5,
+		}),
+	)
+
+	for range 4 {
+		if _, err := client.GetUserData(context.Background(), "bad_user"); err == nil {
+			t.Fatalf("expected error but got none")
+		}
+	}
+
+	if got := client.CircuitBreakerState(); got != helixclient.CircuitBreakerClosed {
+		t.Errorf("expected breaker to be %s, got %s", helixclient.CircuitBreakerClosed, got)
+	}
+}
+
+func TestHalfOpenProbeNotHeldWhileRateLimited(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	// the failure that opens the breaker also exhausts the rate limit until well after it half opens
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Ratelimit-Remaining", "0")
+		w.Header().Set("Ratelimit-Reset", strconv.FormatInt(now.Add(time.Hour).Unix(), 10))
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := helixclient.NewClient(
+		helixclient.WithHelixHost(server.URL),
+		helixclient.WithClock(func() time.Time { return now }),
+		helixclient.WithRetryPolicy(helixclient.RetryPolicy{MaxAttempts: 1}),
+		helixclient.WithCircuitBreakerPolicy(helixclient.CircuitBreakerPolicy{
+			WindowSize:          1,
+			MinRequests:         1,
+			FailureRate:         1,
+			OpenDuration:        time.Second * 30,
+			HalfOpenMaxRequests: 1,
+		}),
+	)
+
+	client.GetUserData(context.Background(), "good_user")
+
+	if got := client.CircuitBreakerState(); got != helixclient.CircuitBreakerOpen {
+		t.Fatalf("expected breaker to be %s, got %s", helixclient.CircuitBreakerOpen, got)
+	}
+
+	now = now.Add(time.Second * 30)
+
+	waiting := make(chan struct{})
+	go func() {
+		defer close(waiting)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*300)
+		defer cancel()
+		client.GetUserData(ctx, "first_user")
+	}()
+	time.Sleep(time.Millisecond * 50)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	_, err := client.GetUserData(ctx, "second_user")
+	if errors.Is(err, helixclient.ErrCircuitOpen) {
+		t.Errorf("expected the request to wait for the rate limit rather than be turned away, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the rate limit wait to time out, got %v", err)
+	}
+
+	<-waiting
+}
+
+func TestProbesInFlightGiveRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	var failing atomic.Bool
+	failing.Store(true)
+	probeSent := make(chan struct{})
+	release := make(chan struct{})
+
+	mux := testutil.StubServerMux()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		close(probeSent)
+		<-release
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	client := helixclient.NewClient(
+		helixclient.WithHelixHost(server.URL),
+		helixclient.WithClock(func() time.Time { return now }),
+		helixclient.WithRetryPolicy(helixclient.RetryPolicy{MaxAttempts: 1}),
+		helixclient.WithCircuitBreakerPolicy(helixclient.CircuitBreakerPolicy{
+			WindowSize:          1,
+			MinRequests:         1,
+			FailureRate:         1,
+			OpenDuration:        time.Second * 30,
+			HalfOpenMaxRequests: 1,
+		}),
+	)
+
+	client.GetUserData(context.Background(), "good_user")
+
+	failing.Store(false)
+	now = now.Add(time.Second * 30)
+
+	probeDone := make(chan struct{})
+	go func() {
+		defer close(probeDone)
+		client.GetUserData(context.Background(), "good_user")
+	}()
+	<-probeSent
+
+	_, err := client.GetUserData(context.Background(), "second_user")
+	close(release)
+	<-probeDone
+
+	var circuitErr *helixclient.CircuitOpenError
+	if !errors.As(err, &circuitErr) {
+		t.Fatalf("expected circuit open error, got %v", err)
+	}
+	if expected := time.Second * 3; circuitErr.RetryAfter != expected {
+		t.Errorf("expected retry after %s, got %s", expected, circuitErr.RetryAfter)
+	}
+}
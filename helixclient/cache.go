@@ -0,0 +1,272 @@
+package helixclient
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultUserCacheTTL    time.Duration = time.Hour
+	DefaultVideoCacheTTL   time.Duration = time.Minute
+	DefaultClipCacheTTL    time.Duration = time.Minute
+	DefaultCacheMaxEntries int           = 1000
+)
+
+// CachedClient wraps an API with in-memory caches of user lookups, video lists and clip lists. Login to
+// ID mappings rarely change so users are kept for longer than videos and clips, whose view counts move
+// constantly. Live streams change by the second and are never cached, nor are errors.
+type CachedClient struct {
+	api API
+
+	users  *lruCache[string, UserInfo]
+	videos *lruCache[videoRequestKey, VideosResponseBody]
+	clips  *lruCache[clipRequestKey, ClipsResponseBody]
+
+	userHits    atomic.Int64
+	userMisses  atomic.Int64
+	videoHits   atomic.Int64
+	videoMisses atomic.Int64
+	clipHits    atomic.Int64
+	clipMisses  atomic.Int64
+}
+
+var _ API = (*CachedClient)(nil)
+
+type CacheStats struct {
+	UserHits     int64 `json:"user_hits"`
+	UserMisses   int64 `json:"user_misses"`
+	UserEntries  int   `json:"user_entries"`
+	VideoHits    int64 `json:"video_hits"`
+	VideoMisses  int64 `json:"video_misses"`
+	VideoEntries int   `json:"video_entries"`
+	ClipHits     int64 `json:"clip_hits"`
+	ClipMisses   int64 `json:"clip_misses"`
+	ClipEntries  int   `json:"clip_entries"`
+}
+
+type cacheConfig struct {
+	userTTL    time.Duration
+	videoTTL   time.Duration
+	clipTTL    time.Duration
+	maxEntries int
+	now        func() time.Time
+}
+
+type CacheOption func(*cacheConfig)
+
+// WithUserCacheTTL sets how long user lookups are cached, a TTL of zero disables user caching.
+func WithUserCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *cacheConfig) {
+		c.userTTL = ttl
+	}
+}
+
+// WithVideoCacheTTL sets how long video lists are cached, a TTL of zero disables video caching.
+func WithVideoCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *cacheConfig) {
+		c.videoTTL = ttl
+	}
+}
+
+// WithClipCacheTTL sets how long clip lists are cached, a TTL of zero disables clip caching.
+func WithClipCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *cacheConfig) {
+		c.clipTTL = ttl
+	}
+}
+
+// WithCacheMaxEntries bounds the number of entries held by each of the user, video and clip caches.
+func WithCacheMaxEntries(maxEntries int) CacheOption {
+	return func(c *cacheConfig) {
+		c.maxEntries = maxEntries
+	}
+}
+
+func WithCacheClock(now func() time.Time) CacheOption {
+	return func(c *cacheConfig) {
+		c.now = now
+	}
+}
+
+func NewCachedClient(api API, opts ...CacheOption) *CachedClient {
+
+	config := cacheConfig{
+		userTTL:    DefaultUserCacheTTL,
+		videoTTL:   DefaultVideoCacheTTL,
+		clipTTL:    DefaultClipCacheTTL,
+		maxEntries: DefaultCacheMaxEntries,
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return &CachedClient{
+		api:    api,
+		users:  newLRUCache[string, UserInfo](config.userTTL, config.maxEntries, config.now),
+		videos: newLRUCache[videoRequestKey, VideosResponseBody](config.videoTTL, config.maxEntries, config.now),
+		clips:  newLRUCache[clipRequestKey, ClipsResponseBody](config.clipTTL, config.maxEntries, config.now),
+	}
+}
+
+func (c *CachedClient) CircuitBreakerState() CircuitBreakerState {
+	return c.api.CircuitBreakerState()
+}
+
+func (c *CachedClient) Stats() CacheStats {
+	return CacheStats{
+		UserHits:     c.userHits.Load(),
+		UserMisses:   c.userMisses.Load(),
+		UserEntries:  c.users.len(),
+		VideoHits:    c.videoHits.Load(),
+		VideoMisses:  c.videoMisses.Load(),
+		VideoEntries: c.videos.len(),
+		ClipHits:     c.clipHits.Load(),
+		ClipMisses:   c.clipMisses.Load(),
+		ClipEntries:  c.clips.len(),
+	}
+}
+
+// GetUserData serves a single cached user when the login is known. Responses that do not contain
+// exactly one user are passed through uncached so callers see helix's answer unchanged.
+func (c *CachedClient) GetUserData(ctx context.Context, userName string) (UsersResponseBody, error) {
+
+	login := strings.ToLower(userName)
+
+	if user, ok := c.users.get(login); ok {
+		c.userHits.Add(1)
+		return UsersResponseBody{Data: []UserInfo{user}}, nil
+	}
+
+	c.userMisses.Add(1)
+
+	responseBody, err := c.api.GetUserData(ctx, userName)
+	if err != nil {
+		return responseBody, err
+	}
+
+	if len(responseBody.Data) == 1 {
+		c.users.set(login, responseBody.Data[0])
+	}
+
+	return responseBody, nil
+}
+
+// LookupUsers serves cached logins and only looks up the remainder upstream. IDs are always looked
+// up upstream as users are cached by login.
+func (c *CachedClient) LookupUsers(ctx context.Context, logins, userIDs []string) (UserLookups, error) {
+
+	lookups := UserLookups{
+		ByLogin: map[string]UserLookup{},
+		ByID:    map[string]UserLookup{},
+	}
+
+	missingLogins := []string{}
+
+	for _, login := range logins {
+		login = strings.ToLower(strings.TrimSpace(login))
+		if _, ok := lookups.ByLogin[login]; login == "" || ok {
+			continue
+		}
+
+		if user, ok := c.users.get(login); ok {
+			c.userHits.Add(1)
+			lookups.ByLogin[login] = UserLookup{UserInfo: user, Found: true}
+			continue
+		}
+
+		c.userMisses.Add(1)
+		missingLogins = append(missingLogins, login)
+	}
+
+	if len(missingLogins) == 0 && len(userIDs) == 0 {
+		return lookups, nil
+	}
+
+	upstream, err := c.api.LookupUsers(ctx, missingLogins, userIDs)
+	if err != nil {
+		return UserLookups{}, err
+	}
+
+	for login, lookup := range upstream.ByLogin {
+		lookups.ByLogin[login] = lookup
+		if lookup.Found {
+			c.users.set(login, lookup.UserInfo)
+		}
+	}
+
+	lookups.ByID = upstream.ByID
+
+	return lookups, nil
+}
+
+func (c *CachedClient) GetStreamerFirstNVideoStatistics(
+	ctx context.Context, userID string, n int, filter VideoFilter,
+) (VideosResponseBody, error) {
+
+	return c.cachedVideos(videoRequestKey{userID: userID, n: n, filter: filter}, func() (VideosResponseBody, error) {
+		return c.api.GetStreamerFirstNVideoStatistics(ctx, userID, n, filter)
+	})
+}
+
+func (c *CachedClient) GetStreamerVideosInWindow(
+	ctx context.Context, userID string, window VideoWindow, n int, filter VideoFilter,
+) (VideosResponseBody, error) {
+
+	return c.cachedVideos(videoRequestKey{userID: userID, n: n, filter: filter, window: window}, func() (VideosResponseBody, error) {
+		return c.api.GetStreamerVideosInWindow(ctx, userID, window, n, filter)
+	})
+}
+
+func (c *CachedClient) cachedVideos(key videoRequestKey, fetch func() (VideosResponseBody, error)) (VideosResponseBody, error) {
+
+	if responseBody, ok := c.videos.get(key); ok {
+		c.videoHits.Add(1)
+		// callers receive their own slice so they cannot modify the cached response
+		responseBody.Data = slices.Clone(responseBody.Data)
+		return responseBody, nil
+	}
+
+	c.videoMisses.Add(1)
+
+	responseBody, err := fetch()
+	if err != nil {
+		return responseBody, err
+	}
+
+	c.videos.set(key, VideosResponseBody{Data: slices.Clone(responseBody.Data), Pagination: responseBody.Pagination})
+
+	return responseBody, nil
+}
+
+func (c *CachedClient) GetStreamerClips(
+	ctx context.Context, broadcasterID string, window VideoWindow, n int,
+) (ClipsResponseBody, error) {
+
+	key := clipRequestKey{broadcasterID: broadcasterID, n: n, window: window}
+
+	if responseBody, ok := c.clips.get(key); ok {
+		c.clipHits.Add(1)
+		responseBody.Data = slices.Clone(responseBody.Data)
+		return responseBody, nil
+	}
+
+	c.clipMisses.Add(1)
+
+	responseBody, err := c.api.GetStreamerClips(ctx, broadcasterID, window, n)
+	if err != nil {
+		return responseBody, err
+	}
+
+	c.clips.set(key, ClipsResponseBody{Data: slices.Clone(responseBody.Data), Pagination: responseBody.Pagination})
+
+	return responseBody, nil
+}
+
+func (c *CachedClient) GetStreams(ctx context.Context, userIDs []string) (StreamsResponseBody, error) {
+	return c.api.GetStreams(ctx, userIDs)
+}
@@ -0,0 +1,228 @@
+package helixclient_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/testutil"
+)
+
+func TestCachedClientGetUserData(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name                 string
+		userTTL              time.Duration
+		elapsed              time.Duration
+		userName             string
+		expectedUserRequests int32
+		expectedStats        helixclient.CacheStats
+	}
+
+	testCases := []testCase{
+		{
+			name:                 "Repeated lookup is served from the cache",
+			userTTL:              time.Hour,
+			elapsed:              time.Minute,
+			userName:             "good_user",
+			expectedUserRequests: 1,
+			expectedStats:        helixclient.CacheStats{UserHits: 1, UserMisses: 1, UserEntries: 1},
+		},
+		{
+			name:                 "Expired entry is looked up again",
+			userTTL:              time.Hour,
+			elapsed:              time.Hour,
+			userName:             "good_user",
+			expectedUserRequests: 2,
+			expectedStats:        helixclient.CacheStats{UserMisses: 2, UserEntries: 1},
+		},
+		{
+			name:                 "Unknown user is not cached",
+			userTTL:              time.Hour,
+			elapsed:              time.Minute,
+			userName:             "no_data_user",
+			expectedUserRequests: 2,
+			expectedStats:        helixclient.CacheStats{UserMisses: 2},
+		},
+		{
+			name:                 "Zero TTL disables the user cache",
+			userTTL:              0,
+			elapsed:              time.Minute,
+			userName:             "good_user",
+			expectedUserRequests: 2,
+			expectedStats:        helixclient.CacheStats{UserMisses: 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var userRequests atomic.Int32
+			server := newCountingStubServer(&userRequests, helixclient.HelixUsersEndpoint)
+			defer server.Close()
+
+			now := time.Now()
+			client := helixclient.NewCachedClient(
+				helixclient.NewClient(helixclient.WithHelixHost(server.URL)),
+				helixclient.WithUserCacheTTL(tc.userTTL),
+				helixclient.WithCacheClock(func() time.Time { return now }),
+			)
+
+			for range 2 {
+				if _, err := client.GetUserData(context.Background(), tc.userName); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				now = now.Add(tc.elapsed)
+			}
+
+			if got := userRequests.Load(); got != tc.expectedUserRequests {
+				t.Errorf("expected %d user requests, got %d", tc.expectedUserRequests, got)
+			}
+			if got := client.Stats(); got != tc.expectedStats {
+				t.Errorf("expected stats %+v, got %+v", tc.expectedStats, got)
+			}
+		})
+	}
+}
+
+func TestCachedClientLookupUsersOnlyFetchesMisses(t *testing.T) {
+	t.Parallel()
+
+	var userRequests atomic.Int32
+	server := newCountingStubServer(&userRequests, helixclient.HelixUsersEndpoint)
+	defer server.Close()
+
+	client := helixclient.NewCachedClient(helixclient.NewClient(helixclient.WithHelixHost(server.URL)))
+
+	if _, err := client.GetUserData(context.Background(), "good_user"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lookups, err := client.LookupUsers(context.Background(), []string{"Good_User", testutil.SecondUserName, "missing_user"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for login, expectedFound := range map[string]bool{"good_user": true, testutil.SecondUserName: true, "missing_user": false} {
+		if got := lookups.ByLogin[login].Found; got != expectedFound {
+			t.Errorf("expected %s found=%t, got %t", login, expectedFound, got)
+		}
+	}
+
+	if _, err := client.LookupUsers(context.Background(), []string{"good_user", testutil.SecondUserName}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := userRequests.Load(); got != 2 {
+		t.Errorf("expected 2 user requests, got %d", got)
+	}
+
+	expectedStats := helixclient.CacheStats{UserHits: 3, UserMisses: 3, UserEntries: 2}
+	if got := client.Stats(); got != expectedStats {
+		t.Errorf("expected stats %+v, got %+v", expectedStats, got)
+	}
+}
+
+func TestCachedClientVideos(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name                  string
+		videoTTL              time.Duration
+		maxEntries            int
+		elapsed               time.Duration
+		requests              []helixclient.VideoFilter
+		expectedVideoRequests int32
+		expectedStats         helixclient.CacheStats
+	}
+
+	archive := helixclient.VideoFilter{Type: helixclient.VideoTypeArchive}
+
+	testCases := []testCase{
+		{
+			name:                  "Repeated request is served from the cache",
+			videoTTL:              time.Minute,
+			maxEntries:            10,
+			elapsed:               time.Second,
+			requests:              []helixclient.VideoFilter{{}, {}, {}},
+			expectedVideoRequests: 1,
+			expectedStats:         helixclient.CacheStats{VideoHits: 2, VideoMisses: 1, VideoEntries: 1},
+		},
+		{
+			name:                  "Different filters are cached separately",
+			videoTTL:              time.Minute,
+			maxEntries:            10,
+			elapsed:               time.Second,
+			requests:              []helixclient.VideoFilter{{}, archive, {}, archive},
+			expectedVideoRequests: 2,
+			expectedStats:         helixclient.CacheStats{VideoHits: 2, VideoMisses: 2, VideoEntries: 2},
+		},
+		{
+			name:                  "Expired entry is fetched again",
+			videoTTL:              time.Minute,
+			maxEntries:            10,
+			elapsed:               time.Minute,
+			requests:              []helixclient.VideoFilter{{}, {}},
+			expectedVideoRequests: 2,
+			expectedStats:         helixclient.CacheStats{VideoMisses: 2, VideoEntries: 1},
+		},
+		{
+			name:                  "Least recently used entry is evicted",
+			videoTTL:              time.Minute,
+			maxEntries:            1,
+			elapsed:               time.Second,
+			requests:              []helixclient.VideoFilter{{}, archive, {}},
+			expectedVideoRequests: 3,
+			expectedStats:         helixclient.CacheStats{VideoMisses: 3, VideoEntries: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var videoRequests atomic.Int32
+			server := newCountingStubServer(&videoRequests, helixclient.HelixVideosEndpoint)
+			defer server.Close()
+
+			now := time.Now()
+			client := helixclient.NewCachedClient(
+				helixclient.NewClient(helixclient.WithHelixHost(server.URL)),
+				helixclient.WithVideoCacheTTL(tc.videoTTL),
+				helixclient.WithCacheMaxEntries(tc.maxEntries),
+				helixclient.WithCacheClock(func() time.Time { return now }),
+			)
+
+			for _, filter := range tc.requests {
+				resp, err := client.GetStreamerFirstNVideoStatistics(context.Background(), "good_user", 2, filter)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(resp.Data) == 0 {
+					t.Fatalf("expected video data, got none")
+				}
+				// modifying a returned response must not leak into the cache
+				resp.Data[0].ViewCount = -1
+				now = now.Add(tc.elapsed)
+			}
+
+			if got := videoRequests.Load(); got != tc.expectedVideoRequests {
+				t.Errorf("expected %d video requests, got %d", tc.expectedVideoRequests, got)
+			}
+			if got := client.Stats(); got != tc.expectedStats {
+				t.Errorf("expected stats %+v, got %+v", tc.expectedStats, got)
+			}
+
+			resp, err := client.GetStreamerFirstNVideoStatistics(context.Background(), "good_user", 2, tc.requests[len(tc.requests)-1])
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Data[0].ViewCount < 0 {
+				t.Errorf("expected cached response to be unaffected by callers")
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package helixclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	HelixClipsEndpoint string = "/clips"
+
+	helixBroadcasterIDURLParam string = "broadcaster_id"
+	helixStartedAtURLParam     string = "started_at"
+	helixEndedAtURLParam       string = "ended_at"
+)
+
+// clipRequestKey identifies identical clip requests, for both coalescing and caching.
+type clipRequestKey struct {
+	broadcasterID string
+	n             int
+	window        VideoWindow
+}
+
+type ClipInfo struct {
+	ID              string    `json:"id"`
+	URL             string    `json:"url"`
+	EmbedURL        string    `json:"embed_url"`
+	BroadcasterID   string    `json:"broadcaster_id"`
+	BroadcasterName string    `json:"broadcaster_name"`
+	CreatorID       string    `json:"creator_id"`
+	CreatorName     string    `json:"creator_name"`
+	VideoID         string    `json:"video_id"`
+	GameID          string    `json:"game_id"`
+	Language        string    `json:"language"`
+	Title           string    `json:"title"`
+	ViewCount       int       `json:"view_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	ThumbnailURL    string    `json:"thumbnail_url"`
+	Duration        float64   `json:"duration"`
+	VODOffset       *int      `json:"vod_offset"`
+	IsFeatured      bool      `json:"is_featured"`
+}
+
+type ClipsResponseBody struct {
+	Data       []ClipInfo        `json:"data"`
+	Pagination map[string]string `json:"pagination"`
+}
+
+// GetStreamerClips returns up to n of the broadcaster's clips created within window, following the Helix
+// pagination cursor across as many pages as required. Helix orders clips by view count, most viewed
+// first. Helix requires a start to bound clips by date, so a window with an Until must also have a
+// Since, and a window with only a Since is bounded by the current time. Concurrent identical requests
+// share one upstream paging run.
+func (c *Client) GetStreamerClips(
+	ctx context.Context, broadcasterID string, window VideoWindow, n int,
+) (responseBody ClipsResponseBody, err error) {
+
+	if n <= 0 {
+		return responseBody, nil
+	}
+
+	if err := window.Validate(); err != nil {
+		return responseBody, fmt.Errorf("message=%s error=%v", "invalid clip window", err)
+	}
+
+	if window.Since.IsZero() && !window.Until.IsZero() {
+		return responseBody, fmt.Errorf("message=%s", "clip window requires since when until is provided")
+	}
+
+	key := clipRequestKey{broadcasterID: broadcasterID, n: n, window: window}
+
+	responseBody, shared, err := c.clipFlights.do(ctx, key, func(ctx context.Context) (ClipsResponseBody, error) {
+		return c.pageClips(ctx, broadcasterID, window, n)
+	})
+
+	if shared {
+		responseBody.Data = slices.Clone(responseBody.Data)
+	}
+
+	return responseBody, err
+}
+
+func (c *Client) pageClips(
+	ctx context.Context, broadcasterID string, window VideoWindow, n int,
+) (responseBody ClipsResponseBody, err error) {
+
+	endpoint, err := url.Parse(c.helixHost)
+	if err != nil {
+		return responseBody, err
+	}
+
+	endpoint.Path = path.Join(endpoint.Path, HelixClipsEndpoint)
+
+	cursor := ""
+
+	for len(responseBody.Data) < n {
+
+		if err := ctx.Err(); err != nil {
+			return ClipsResponseBody{}, fmt.Errorf("message=%s error=%v", "clip pagination cancelled", err)
+		}
+
+		queryParams := url.Values{
+			helixBroadcasterIDURLParam: {broadcasterID},
+			helixFirstURLParam:         {strconv.Itoa(min(n-len(responseBody.Data), helixMaxPageSize))},
+		}
+
+		if !window.Since.IsZero() {
+			until := window.Until
+			if until.IsZero() {
+				until = c.now()
+			}
+			queryParams.Set(helixStartedAtURLParam, window.Since.UTC().Format(time.RFC3339))
+			queryParams.Set(helixEndedAtURLParam, until.UTC().Format(time.RFC3339))
+		}
+
+		if cursor != "" {
+			queryParams.Set(helixAfterURLParam, cursor)
+		}
+
+		page, err := executeAuthorisedRequest[ClipsResponseBody](ctx, c, endpoint, queryParams)
+		if err != nil {
+			return ClipsResponseBody{}, err
+		}
+
+		responseBody.Data = append(responseBody.Data, page.Data...)
+		responseBody.Pagination = page.Pagination
+
+		// helix may return a cursor alongside an empty page once the clips run out
+		cursor = page.Pagination[helixPaginationCursorKey]
+		if cursor == "" || len(page.Data) == 0 {
+			break
+		}
+	}
+
+	if len(responseBody.Data) > n {
+		responseBody.Data = responseBody.Data[:n]
+	}
+
+	return responseBody, nil
+}
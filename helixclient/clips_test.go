@@ -0,0 +1,142 @@
+package helixclient_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/testutil"
+)
+
+func TestGetStreamerClips(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(testutil.StubServerMux())
+	defer server.Close()
+	client := helixclient.NewClient(helixclient.WithHelixHost(server.URL))
+
+	// paginated clip i was created i hours before PaginatedVideosNewest
+	clipCreatedAt := func(i int) time.Time {
+		return testutil.PaginatedVideosNewest.Add(-time.Duration(i) * time.Hour)
+	}
+
+	type testCase struct {
+		name          string
+		window        helixclient.VideoWindow
+		n             int
+		expectError   bool
+		expectedLen   int
+		expectedFirst string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "N spanning several pages",
+			n:             150,
+			expectedLen:   150,
+			expectedFirst: "c0",
+		},
+		{
+			name:          "N greater than the number of clips",
+			n:             1000,
+			expectedLen:   testutil.PaginatedClipCount,
+			expectedFirst: "c0",
+		},
+		{
+			name:          "Window spanning a page boundary",
+			window:        helixclient.VideoWindow{Since: clipCreatedAt(129), Until: clipCreatedAt(90)},
+			n:             1000,
+			expectedLen:   40,
+			expectedFirst: "c90",
+		},
+		{
+			name:          "Window without an end is bounded by now",
+			window:        helixclient.VideoWindow{Since: clipCreatedAt(9)},
+			n:             1000,
+			expectedLen:   10,
+			expectedFirst: "c0",
+		},
+		{
+			name:        "Zero N returns no clips",
+			n:           0,
+			expectedLen: 0,
+		},
+		{
+			name:        "Until without since returns error",
+			window:      helixclient.VideoWindow{Until: clipCreatedAt(9)},
+			n:           10,
+			expectError: true,
+		},
+		{
+			name:        "Since after until returns error",
+			window:      helixclient.VideoWindow{Since: clipCreatedAt(1), Until: clipCreatedAt(2)},
+			n:           10,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := client.GetStreamerClips(context.Background(), testutil.PaginatedUserID, tc.window, tc.n)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(resp.Data) != tc.expectedLen {
+				t.Fatalf("expected %d clip data entries, got %d", tc.expectedLen, len(resp.Data))
+			}
+			if tc.expectedLen > 0 && resp.Data[0].ID != tc.expectedFirst {
+				t.Errorf("expected first clip %s, got %s", tc.expectedFirst, resp.Data[0].ID)
+			}
+		})
+	}
+}
+
+func TestCachedClientClips(t *testing.T) {
+	t.Parallel()
+
+	var clipRequests atomic.Int32
+	server := newCountingStubServer(&clipRequests, helixclient.HelixClipsEndpoint)
+	defer server.Close()
+
+	client := helixclient.NewCachedClient(
+		helixclient.NewClient(helixclient.WithHelixHost(server.URL)),
+		helixclient.WithClipCacheTTL(time.Minute),
+	)
+
+	for range 3 {
+		resp, err := client.GetStreamerClips(context.Background(), "good_user", helixclient.VideoWindow{}, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Data) == 0 {
+			t.Fatalf("expected clip data, got none")
+		}
+		// modifying a returned response must not leak into the cache
+		resp.Data[0].ViewCount = -1
+	}
+
+	if got := clipRequests.Load(); got != 1 {
+		t.Errorf("expected 1 clip request, got %d", got)
+	}
+
+	expectedStats := helixclient.CacheStats{ClipHits: 2, ClipMisses: 1, ClipEntries: 1}
+	if got := client.Stats(); got != expectedStats {
+		t.Errorf("expected stats %+v, got %+v", expectedStats, got)
+	}
+
+	resp, err := client.GetStreamerClips(context.Background(), "good_user", helixclient.VideoWindow{}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Data[0].ViewCount < 0 {
+		t.Errorf("expected cached response to be unaffected by callers")
+	}
+}
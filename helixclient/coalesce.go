@@ -0,0 +1,152 @@
+package helixclient
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// videoRequestKey identifies identical video requests, for both coalescing and caching.
+type videoRequestKey struct {
+	userID string
+	n      int
+	filter VideoFilter
+	window VideoWindow
+}
+
+// flightGroup coalesces concurrent calls with the same key into one upstream call whose result is shared
+// by every caller. The call runs on a context detached from the caller that started it, so one caller
+// giving up does not fail the others; it is only cancelled once every caller waiting on it has given up.
+// The call's context reports the latest deadline of the callers still waiting, so work that checks the
+// deadline, such as retries, neither gives up early nor outlives every caller.
+type flightGroup[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*flight[V]
+}
+
+type flight[V any] struct {
+	done    chan struct{}
+	value   V
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+
+	// deadlines holds the deadline of each waiting caller, unbounded counts those without one
+	deadlines []time.Time
+	unbounded int
+}
+
+// flightContext is the context of a coalesced call, overriding Deadline with the latest deadline of the
+// callers waiting on it.
+type flightContext[K comparable, V any] struct {
+	context.Context
+	group *flightGroup[K, V]
+	call  *flight[V]
+}
+
+func (c flightContext[K, V]) Deadline() (deadline time.Time, ok bool) {
+	c.group.mu.Lock()
+	defer c.group.mu.Unlock()
+
+	if c.call.unbounded > 0 || len(c.call.deadlines) == 0 {
+		return deadline, false
+	}
+
+	return slices.MaxFunc(c.call.deadlines, time.Time.Compare), true
+}
+
+func (f *flight[V]) join(ctx context.Context) {
+	f.waiters++
+	if deadline, ok := ctx.Deadline(); ok {
+		f.deadlines = append(f.deadlines, deadline)
+	} else {
+		f.unbounded++
+	}
+}
+
+func (f *flight[V]) leave(ctx context.Context) {
+	f.waiters--
+	if deadline, ok := ctx.Deadline(); ok {
+		if i := slices.IndexFunc(f.deadlines, deadline.Equal); i >= 0 {
+			f.deadlines = slices.Delete(f.deadlines, i, i+1)
+		}
+	} else {
+		f.unbounded--
+	}
+}
+
+func newFlightGroup[K comparable, V any]() *flightGroup[K, V] {
+	return &flightGroup[K, V]{
+		calls: map[K]*flight[V]{},
+	}
+}
+
+// do returns the result of fn for key, joining a call already in flight for key if there is one.
+// shared reports whether the result may have been handed to more than one caller.
+func (g *flightGroup[K, V]) do(
+	ctx context.Context, key K, fn func(ctx context.Context) (V, error),
+) (value V, shared bool, err error) {
+
+	g.mu.Lock()
+
+	call, ok := g.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &flight[V]{
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		g.calls[key] = call
+
+		go g.run(flightContext[K, V]{Context: callCtx, group: g, call: call}, key, call, fn)
+	}
+	call.join(ctx)
+
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		g.mu.Lock()
+		shared = call.waiters > 1
+		g.mu.Unlock()
+		return call.value, shared, call.err
+	case <-ctx.Done():
+		g.leave(ctx, key, call)
+		return value, false, ctx.Err()
+	}
+}
+
+func (g *flightGroup[K, V]) run(ctx context.Context, key K, call *flight[V], fn func(ctx context.Context) (V, error)) {
+
+	value, err := fn(ctx)
+
+	g.mu.Lock()
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	call.value = value
+	call.err = err
+	g.mu.Unlock()
+
+	close(call.done)
+	call.cancel()
+}
+
+// leave stops waiting on call, cancelling it once nobody is waiting. The call is forgotten at the same
+// time so later callers start a fresh call rather than joining one that is being cancelled.
+func (g *flightGroup[K, V]) leave(ctx context.Context, key K, call *flight[V]) {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	call.leave(ctx)
+	if call.waiters > 0 {
+		return
+	}
+
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	call.cancel()
+}
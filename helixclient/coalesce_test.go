@@ -0,0 +1,178 @@
+package helixclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/testutil"
+)
+
+// newBlockingStubServer serves the stub helix API, holding /users requests until release is closed.
+// Requests cancelled while held are reported on cancelled.
+func newBlockingStubServer(counter *atomic.Int32, release <-chan struct{}, cancelled chan<- struct{}) *httptest.Server {
+	mux := testutil.StubServerMux()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == helixclient.HelixUsersEndpoint {
+			counter.Add(1)
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				cancelled <- struct{}{}
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func awaitUserDataWaiters(t *testing.T, client *helixclient.Client, userName string, waiters int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for helixclient.UserDataWaiters(client, userName) != waiters {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d waiters, got %d", waiters, helixclient.UserDataWaiters(client, userName))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcurrentGetUserDataSharesOneRequest(t *testing.T) {
+	t.Parallel()
+
+	var userRequests atomic.Int32
+	release := make(chan struct{})
+	server := newBlockingStubServer(&userRequests, release, make(chan struct{}, 1))
+	defer server.Close()
+
+	client := helixclient.NewClient(helixclient.WithHelixHost(server.URL))
+
+	const callers = 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.GetUserData(context.Background(), "good_user")
+			if err == nil && len(resp.Data) != 1 {
+				err = errors.New("expected 1 user data entry")
+			}
+			errs <- err
+		}()
+	}
+
+	awaitUserDataWaiters(t, client, "good_user", callers)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	if got := userRequests.Load(); got != 1 {
+		t.Errorf("expected 1 user request, got %d", got)
+	}
+}
+
+func TestGetUserDataLeaderCancellation(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name                 string
+		cancelFollower       bool
+		expectUpstreamCancel bool
+	}
+
+	testCases := []testCase{
+		{
+			name:                 "Follower receives the result when the leader is cancelled",
+			cancelFollower:       false,
+			expectUpstreamCancel: false,
+		},
+		{
+			name:                 "Upstream request is cancelled once every caller is cancelled",
+			cancelFollower:       true,
+			expectUpstreamCancel: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var userRequests atomic.Int32
+			release := make(chan struct{})
+			cancelled := make(chan struct{}, 1)
+			server := newBlockingStubServer(&userRequests, release, cancelled)
+			defer server.Close()
+			defer close(release)
+
+			client := helixclient.NewClient(helixclient.WithHelixHost(server.URL))
+
+			leaderCtx, cancelLeader := context.WithCancel(context.Background())
+			followerCtx, cancelFollower := context.WithCancel(context.Background())
+			defer cancelFollower()
+
+			leaderErr := make(chan error, 1)
+			go func() {
+				_, err := client.GetUserData(leaderCtx, "good_user")
+				leaderErr <- err
+			}()
+			awaitUserDataWaiters(t, client, "good_user", 1)
+
+			followerErr := make(chan error, 1)
+			go func() {
+				resp, err := client.GetUserData(followerCtx, "good_user")
+				if err == nil && len(resp.Data) != 1 {
+					err = errors.New("expected 1 user data entry")
+				}
+				followerErr <- err
+			}()
+			awaitUserDataWaiters(t, client, "good_user", 2)
+
+			cancelLeader()
+			if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected leader to return context.Canceled, got %v", err)
+			}
+
+			if tc.cancelFollower {
+				cancelFollower()
+				if err := <-followerErr; !errors.Is(err, context.Canceled) {
+					t.Fatalf("expected follower to return context.Canceled, got %v", err)
+				}
+			} else {
+				release <- struct{}{}
+				if err := <-followerErr; err != nil {
+					t.Fatalf("unexpected follower error: %v", err)
+				}
+			}
+
+			select {
+			case <-cancelled:
+				if !tc.expectUpstreamCancel {
+					t.Errorf("expected upstream request to complete, but it was cancelled")
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tc.expectUpstreamCancel {
+					t.Errorf("expected upstream request to be cancelled")
+				}
+			}
+
+			if got := userRequests.Load(); got != 1 {
+				t.Errorf("expected 1 user request, got %d", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package helixclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxErrorBodySize bounds how much of an error response body is kept on an APIError
+const maxErrorBodySize int64 = 4096
+
+// APIError is returned when helix, or the OAuth server, responds with a status other than 200 OK.
+// Use errors.As to obtain it.
+type APIError struct {
+	URL        string
+	StatusCode int
+	// HelixError and HelixMessage hold the error and message fields of a helix error body, e.g.
+	// "Unauthorized" and "Invalid OAuth token", and are empty if the body was not a helix error
+	HelixError   string
+	HelixMessage string
+	// Body is the raw response body, truncated to 4KiB
+	Body string
+	// RetryAfter is the delay requested by the Retry-After header, zero if there was none
+	RetryAfter time.Duration
+	// Retryable reports whether the status is one the client's RetryPolicy retries
+	Retryable bool
+}
+
+func (e *APIError) Error() string {
+
+	message := fmt.Sprintf("message=%s url=%s status_code=%d", "received unexpected status code", e.URL, e.StatusCode)
+
+	if e.HelixMessage != "" {
+		message += fmt.Sprintf(" helix_error=%q helix_message=%q", e.HelixError, e.HelixMessage)
+	}
+
+	return message
+}
+
+// helixErrorBody is the body helix responds with alongside an error status
+type helixErrorBody struct {
+	Error   string `json:"error"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+func (c *Client) newAPIError(url string, response *http.Response) *APIError {
+
+	apiErr := &APIError{
+		URL:        url,
+		StatusCode: response.StatusCode,
+		RetryAfter: parseRetryAfter(response.Header, c.now()),
+		Retryable:  slices.Contains(c.retryPolicy.RetryableStatusCodes, response.StatusCode),
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+	if err != nil {
+		return apiErr
+	}
+
+	apiErr.Body = strings.TrimSpace(string(body))
+
+	var helixErr helixErrorBody
+	if json.Unmarshal(body, &helixErr) == nil {
+		apiErr.HelixError = helixErr.Error
+		apiErr.HelixMessage = helixErr.Message
+	}
+
+	return apiErr
+}
+
+// AuthError is returned when the client cannot obtain an access token from the OAuth server. It wraps
+// the APIError or TransportError describing why, which is a fault of the client's credentials or of
+// the OAuth server rather than of the request being made. Use errors.As to obtain it.
+type AuthError struct {
+	Err error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("message=%s error=%v", "failed to get authorisation for helix client", e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// TransportError is returned when a request fails without a response from helix, for example because
+// the connection was refused or the request timed out. Use errors.As to obtain it.
+type TransportError struct {
+	URL string
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("message=%s url=%s error=%v", "failed to execute http request", e.URL, e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports whether the request failed because it took too long.
+func (e *TransportError) Timeout() bool {
+
+	var timeoutErr interface{ Timeout() bool }
+	if errors.As(e.Err, &timeoutErr) && timeoutErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(e.Err, context.DeadlineExceeded)
+}
@@ -0,0 +1,152 @@
+package helixclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/testutil"
+)
+
+func TestAPIError(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name          string
+		status        int
+		body          string
+		header        http.Header
+		expectedError helixclient.APIError
+	}
+
+	testCases := []testCase{
+		{
+			name:   "Helix error body is decoded",
+			status: http.StatusUnauthorized,
+			body:   `{"error":"Unauthorized","status":401,"message":"Invalid OAuth token"}`,
+			expectedError: helixclient.APIError{
+				StatusCode:   http.StatusUnauthorized,
+				HelixError:   "Unauthorized",
+				HelixMessage: "Invalid OAuth token",
+				Body:         `{"error":"Unauthorized","status":401,"message":"Invalid OAuth token"}`,
+			},
+		},
+		{
+			name:   "Plain text body is kept",
+			status: http.StatusBadRequest,
+			body:   "bad request\n",
+			expectedError: helixclient.APIError{
+				StatusCode: http.StatusBadRequest,
+				Body:       "bad request",
+			},
+		},
+		{
+			name:   "Retryable status reports its Retry-After",
+			status: http.StatusServiceUnavailable,
+			header: http.Header{"Retry-After": {"3"}},
+			expectedError: helixclient.APIError{
+				StatusCode: http.StatusServiceUnavailable,
+				RetryAfter: time.Second * 3,
+				Retryable:  true,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mux := testutil.StubServerMux()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == helixclient.HelixTokenEndpoint {
+					mux.ServeHTTP(w, r)
+					return
+				}
+				for key, values := range tc.header {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			client := helixclient.NewClient(
+				helixclient.WithHelixHost(server.URL),
+				helixclient.WithAuthHost(server.URL),
+				helixclient.WithCredentials(testutil.StubClientID, testutil.StubClientSecret),
+				helixclient.WithRetryPolicy(helixclient.RetryPolicy{MaxAttempts: 1, RetryableStatusCodes: helixclient.DefaultRetryableStatusCodes}),
+			)
+
+			_, err := client.GetUserData(context.Background(), "good_user")
+
+			var apiErr *helixclient.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected *helixclient.APIError, got %v", err)
+			}
+
+			tc.expectedError.URL = server.URL + "/users?login=good_user"
+			if *apiErr != tc.expectedError {
+				t.Errorf("\nwant %+v\n got %+v", tc.expectedError, *apiErr)
+			}
+		})
+	}
+}
+
+func TestTransportError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond * 200)
+	}))
+	defer server.Close()
+
+	client := helixclient.NewClient(
+		helixclient.WithHelixHost(server.URL),
+		helixclient.WithHTTPClient(&http.Client{Timeout: time.Millisecond * 50}),
+		helixclient.WithRetryPolicy(helixclient.RetryPolicy{MaxAttempts: 1}),
+	)
+
+	_, err := client.GetUserData(context.Background(), "good_user")
+
+	var transportErr *helixclient.TransportError
+	if !errors.As(err, &transportErr) {
+		t.Fatalf("expected *helixclient.TransportError, got %v", err)
+	}
+	if !transportErr.Timeout() {
+		t.Errorf("expected transport error to be a timeout")
+	}
+}
+
+func TestAuthError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":400,"message":"invalid client"}`))
+	}))
+	defer server.Close()
+
+	client := helixclient.NewClient(
+		helixclient.WithAuthHost(server.URL),
+		helixclient.WithCredentials(testutil.StubClientID, "wrong_secret"),
+		helixclient.WithRetryPolicy(helixclient.RetryPolicy{MaxAttempts: 1}),
+	)
+
+	err := client.Authenticate(context.Background())
+
+	var authErr *helixclient.AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected *helixclient.AuthError, got %v", err)
+	}
+
+	var apiErr *helixclient.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected the *helixclient.AuthError to wrap a *helixclient.APIError, got %v", authErr.Err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.HelixMessage != "invalid client" {
+		t.Errorf("unexpected api error: %+v", apiErr)
+	}
+}
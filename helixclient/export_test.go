@@ -0,0 +1,27 @@
+package helixclient
+
+import (
+	"strings"
+	"time"
+)
+
+func SetAccessToken(c *Client, accessToken string, expiry time.Time) {
+	c.accessTokenMutex.Lock()
+	defer c.accessTokenMutex.Unlock()
+
+	c.accessToken = accessToken
+	c.accessTokenExpiry = expiry
+}
+
+// UserDataWaiters reports how many callers are waiting on an in-flight lookup of userName.
+func UserDataWaiters(c *Client, userName string) int {
+	c.userFlights.mu.Lock()
+	defer c.userFlights.mu.Unlock()
+
+	call, ok := c.userFlights.calls[strings.ToLower(userName)]
+	if !ok {
+		return 0
+	}
+
+	return call.waiters
+}
@@ -3,125 +3,467 @@ package helixclient
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"ttv-statistics/constants"
 )
 
 const (
-	getHelixAuthEndpoint string = "https://id.twitch.tv/oauth2/token" // currently hardcoded constant, this could be parsed as a CLI flag for flexibility
+	DefaultHelixAuthHost string = "https://id.twitch.tv"
+
+	// tokenRefreshMargin is how long before expiry the access token is proactively refreshed
+	tokenRefreshMargin time.Duration = time.Minute * 5
 
 	helixLoginURLParam  string = "login"
 	helixUserIDURLParam string = "user_id"
 	helixFirstURLParam  string = "first"
+	helixAfterURLParam  string = "after"
+
+	helixPaginationCursorKey string = "cursor"
+	helixMaxPageSize         int    = 100
 
 	HelixUsersEndpoint  string = "/users"
 	HelixVideosEndpoint string = "/videos"
+	HelixTokenEndpoint  string = "/oauth2/token"
 
 	authorisationHeaderKey string = "Authorization"
 	clientIDHeaderKey      string = "Client-ID"
 )
 
-var (
-	ClientID     string
-	ClientSecret string
-	HelixHost    string
+// API is the subset of the helix API consumed by the ttv-statistics handlers.
+type API interface {
+	GetUserData(ctx context.Context, userName string) (UsersResponseBody, error)
+	LookupUsers(ctx context.Context, logins, userIDs []string) (UserLookups, error)
+	GetStreamerFirstNVideoStatistics(ctx context.Context, userID string, n int, filter VideoFilter) (VideosResponseBody, error)
+	GetStreamerVideosInWindow(ctx context.Context, userID string, window VideoWindow, n int, filter VideoFilter) (VideosResponseBody, error)
+	GetStreamerClips(ctx context.Context, broadcasterID string, window VideoWindow, n int) (ClipsResponseBody, error)
+	GetStreams(ctx context.Context, userIDs []string) (StreamsResponseBody, error)
+	CircuitBreakerState() CircuitBreakerState
+}
+
+// Client is a helix API client holding its own credentials, access token and rate limit state,
+// making it safe to run several clients with different credentials in one process.
+type Client struct {
+	clientID     string
+	clientSecret string
+	helixHost    string
+	authHost     string
+	httpClient   *http.Client
+	now          func() time.Time
+
+	accessToken       string
+	accessTokenExpiry time.Time
+	accessTokenMutex  sync.Mutex
+
+	rateLimiter *rateLimiter
+	retryPolicy RetryPolicy
+
+	circuitBreakerPolicy CircuitBreakerPolicy
+	circuitBreaker       *circuitBreaker
+
+	userFlights   *flightGroup[string, UsersResponseBody]
+	videoFlights  *flightGroup[videoRequestKey, VideosResponseBody]
+	clipFlights   *flightGroup[clipRequestKey, ClipsResponseBody]
+	streamFlights *flightGroup[string, StreamsResponseBody]
+}
 
-	helixAccessToken string
+var _ API = (*Client)(nil)
+
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
 
-	helixClient = &http.Client{
-		Timeout: time.Second * 10,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 100,
-			IdleConnTimeout:     time.Minute * 2,
+func WithHelixHost(helixHost string) Option {
+	return func(c *Client) {
+		c.helixHost = helixHost
+	}
+}
+
+// WithAuthHost sets the host of the OAuth server issuing access tokens, allowing the
+// client to authenticate against a local stand-in for id.twitch.tv.
+func WithAuthHost(authHost string) Option {
+	return func(c *Client) {
+		c.authHost = authHost
+	}
+}
+
+func WithCredentials(clientID, clientSecret string) Option {
+	return func(c *Client) {
+		c.clientID = clientID
+		c.clientSecret = clientSecret
+	}
+}
+
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
+
+func NewClient(opts ...Option) *Client {
+
+	c := &Client{
+		authHost: DefaultHelixAuthHost,
+		httpClient: &http.Client{
+			Timeout: time.Second * 10,
+			Transport: &http.Transport{
+				MaxIdleConns:        100,
+				MaxIdleConnsPerHost: 100,
+				IdleConnTimeout:     time.Minute * 2,
+			},
 		},
+		now:                  time.Now,
+		retryPolicy:          DefaultRetryPolicy(),
+		circuitBreakerPolicy: DefaultCircuitBreakerPolicy(),
 	}
-)
 
-func InitHelixClientAuth(ctx context.Context) error {
-	response, err := getHelixAccessToken(ctx)
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.rateLimiter = newRateLimiter(defaultRateLimit, c.now)
+	c.circuitBreaker = newCircuitBreaker(c.circuitBreakerPolicy, c.now)
+	c.userFlights = newFlightGroup[string, UsersResponseBody]()
+	c.videoFlights = newFlightGroup[videoRequestKey, VideosResponseBody]()
+	c.clipFlights = newFlightGroup[clipRequestKey, ClipsResponseBody]()
+	c.streamFlights = newFlightGroup[string, StreamsResponseBody]()
+
+	return c
+}
+
+func (c *Client) HelixHost() string {
+	return c.helixHost
+}
+
+// CircuitBreakerState reports whether requests are currently reaching helix.
+func (c *Client) CircuitBreakerState() CircuitBreakerState {
+	return c.circuitBreaker.currentState()
+}
+
+func (c *Client) AuthHost() string {
+	return c.authHost
+}
+
+func (c *Client) Authenticate(ctx context.Context) error {
+	c.accessTokenMutex.Lock()
+	defer c.accessTokenMutex.Unlock()
+
+	return c.refreshAccessToken(ctx)
+}
+
+// refreshAccessToken must be called while holding accessTokenMutex
+func (c *Client) refreshAccessToken(ctx context.Context) error {
+	response, err := c.getHelixAccessToken(ctx)
 	if err != nil {
-		return fmt.Errorf("message=%q error=%v", "failed to get authorisation for helix client", err)
+		return &AuthError{Err: err}
+	}
+
+	c.accessToken = response.AccessToken
+	c.accessTokenExpiry = time.Time{}
+	if response.ExpiresIn > 0 {
+		c.accessTokenExpiry = c.now().Add(time.Duration(response.ExpiresIn) * time.Second)
 	}
 
-	helixAccessToken = response.AccessToken
 	return nil
 }
 
-func GetUserData(ctx context.Context, userName string) (responseBody UsersResponseBody, err error) {
+// currentAccessToken returns the cached access token, refreshing it first if it is due to expire.
+// A token that has never been obtained is left to the 401 handling in executeAuthorisedRequest.
+func (c *Client) currentAccessToken(ctx context.Context) (string, error) {
+	c.accessTokenMutex.Lock()
+	defer c.accessTokenMutex.Unlock()
+
+	if !c.accessTokenExpiry.IsZero() && c.now().After(c.accessTokenExpiry.Add(-tokenRefreshMargin)) {
+		if err := c.refreshAccessToken(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	return c.accessToken, nil
+}
+
+// reauthenticate refreshes the access token unless another request has already replaced the
+// rejected token, in which case the newer token is reused.
+func (c *Client) reauthenticate(ctx context.Context, rejectedToken string) (string, error) {
+	c.accessTokenMutex.Lock()
+	defer c.accessTokenMutex.Unlock()
+
+	if c.accessToken == rejectedToken {
+		if err := c.refreshAccessToken(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	return c.accessToken, nil
+}
+
+// GetUserData looks up a user by login. Concurrent lookups of the same login share one upstream request.
+func (c *Client) GetUserData(ctx context.Context, userName string) (responseBody UsersResponseBody, err error) {
+
+	responseBody, shared, err := c.userFlights.do(ctx, strings.ToLower(userName), func(ctx context.Context) (UsersResponseBody, error) {
+		return c.getUserData(ctx, userName)
+	})
+
+	if shared {
+		responseBody.Data = slices.Clone(responseBody.Data)
+	}
+
+	return responseBody, err
+}
+
+func (c *Client) getUserData(ctx context.Context, userName string) (responseBody UsersResponseBody, err error) {
 
-	endpoint, err := url.Parse(HelixHost)
+	endpoint, err := url.Parse(c.helixHost)
 	if err != nil {
 		return responseBody, err
 	}
 
 	endpoint.Path = path.Join(endpoint.Path, HelixUsersEndpoint)
 
-	headers := generateHeaders()
+	queryParams := url.Values{
+		helixLoginURLParam: {userName},
+	}
+
+	return executeAuthorisedRequest[UsersResponseBody](ctx, c, endpoint, queryParams)
+}
+
+// GetStreamerFirstNVideoStatistics returns up to n of the user's videos matching filter, following the
+// Helix pagination cursor across as many pages as required. Fewer than n videos are returned if the
+// channel runs out of videos first.
+func (c *Client) GetStreamerFirstNVideoStatistics(
+	ctx context.Context, userID string, n int, filter VideoFilter,
+) (responseBody VideosResponseBody, err error) {
 
-	queryParams := map[string]string{
-		helixLoginURLParam: userName,
+	if n <= 0 {
+		return responseBody, nil
 	}
 
-	return executeRequest[UsersResponseBody](ctx, http.MethodGet, endpoint, queryParams, headers, nil)
+	return c.collectVideos(ctx, userID, n, filter, VideoWindow{})
 }
 
-func GetStreamerFirstNVideoStatistics(ctx context.Context, userID string, n int) (responseBody VideosResponseBody, err error) {
-	endpoint, err := url.Parse(HelixHost)
+// GetStreamerVideosInWindow returns the user's videos matching filter that were created within window,
+// capped at n videos when n is positive. Videos are paged newest first, so paging stops as soon as a
+// video older than the start of the window is seen.
+func (c *Client) GetStreamerVideosInWindow(
+	ctx context.Context, userID string, window VideoWindow, n int, filter VideoFilter,
+) (responseBody VideosResponseBody, err error) {
+
+	if err := window.Validate(); err != nil {
+		return responseBody, fmt.Errorf("message=%s error=%v", "invalid video window", err)
+	}
+
+	if filter.Sort != "" && filter.Sort != VideoSortTime {
+		return responseBody, fmt.Errorf("message=%s sort=%s", "video window requires videos sorted by time", filter.Sort)
+	}
+
+	return c.collectVideos(ctx, userID, n, filter, window)
+}
+
+// collectVideos pages through the user's videos, sharing one upstream paging run between concurrent
+// identical requests.
+func (c *Client) collectVideos(
+	ctx context.Context, userID string, n int, filter VideoFilter, window VideoWindow,
+) (responseBody VideosResponseBody, err error) {
+
+	key := videoRequestKey{userID: userID, n: n, filter: filter, window: window}
+
+	responseBody, shared, err := c.videoFlights.do(ctx, key, func(ctx context.Context) (VideosResponseBody, error) {
+		return c.pageVideos(ctx, userID, n, filter, window)
+	})
+
+	if shared {
+		responseBody.Data = slices.Clone(responseBody.Data)
+	}
+
+	return responseBody, err
+}
+
+func (c *Client) pageVideos(
+	ctx context.Context, userID string, n int, filter VideoFilter, window VideoWindow,
+) (responseBody VideosResponseBody, err error) {
+
+	if err := filter.Validate(); err != nil {
+		return responseBody, fmt.Errorf("message=%s error=%v", "invalid video filter", err)
+	}
+
+	endpoint, err := url.Parse(c.helixHost)
 	if err != nil {
 		return responseBody, err
 	}
 
 	endpoint.Path = path.Join(endpoint.Path, HelixVideosEndpoint)
 
-	headers := generateHeaders()
+	cursor := ""
+
+	for n <= 0 || len(responseBody.Data) < n {
+
+		if err := ctx.Err(); err != nil {
+			return VideosResponseBody{}, fmt.Errorf("message=%s error=%v", "video pagination cancelled", err)
+		}
+
+		// without a cap every page is requested in full, as helix rejects a first below 1
+		pageSize := helixMaxPageSize
+		if window.IsZero() && n > 0 {
+			pageSize = min(n-len(responseBody.Data), helixMaxPageSize)
+		}
+
+		queryParams := filter.queryParams()
+		queryParams.Set(helixUserIDURLParam, userID)
+		queryParams.Set(helixFirstURLParam, strconv.Itoa(pageSize))
+
+		if cursor != "" {
+			queryParams.Set(helixAfterURLParam, cursor)
+		}
+
+		page, err := executeAuthorisedRequest[VideosResponseBody](ctx, c, endpoint, queryParams)
+		if err != nil {
+			return VideosResponseBody{}, err
+		}
 
-	queryParams := map[string]string{
-		helixUserIDURLParam: userID,
-		helixFirstURLParam:  strconv.Itoa(n),
+		windowPassed := false
+		for _, video := range page.Data {
+			if window.Contains(video.CreatedAt) {
+				responseBody.Data = append(responseBody.Data, video)
+			}
+			if window.Before(video.CreatedAt) {
+				windowPassed = true
+				break
+			}
+		}
+
+		responseBody.Pagination = page.Pagination
+
+		cursor = page.Pagination[helixPaginationCursorKey]
+		if cursor == "" || len(page.Data) == 0 || windowPassed {
+			break
+		}
 	}
 
-	return executeRequest[VideosResponseBody](ctx, http.MethodGet, endpoint, queryParams, headers, nil)
+	if n > 0 && len(responseBody.Data) > n {
+		responseBody.Data = responseBody.Data[:n]
+	}
+
+	return responseBody, nil
 }
 
-func generateHeaders() map[string]string {
+func (c *Client) generateHeaders(accessToken string) map[string]string {
 	return map[string]string{
-		clientIDHeaderKey:      ClientID,
-		authorisationHeaderKey: fmt.Sprintf("Bearer %s", helixAccessToken),
+		clientIDHeaderKey:      c.clientID,
+		authorisationHeaderKey: fmt.Sprintf("Bearer %s", accessToken),
+	}
+}
+
+// executeAuthorisedRequest performs a rate limited GET against the helix API. If helix rejects the
+// access token the client re-authenticates once and replays the request. Transient failures are retried
+// according to the client's RetryPolicy. 429 Too Many Requests always empties the rate limit bucket, so
+// the next request waits for it to refill, whether that is a replay, which only happens when the
+// RetryPolicy retries 429, or the client's next call. While the circuit breaker is open requests fail
+// fast with ErrCircuitOpen.
+func executeAuthorisedRequest[T ClientResponseModels](
+	ctx context.Context, c *Client, endpoint *url.URL, queryParams url.Values,
+) (responseBody T, err error) {
+
+	accessToken, err := c.currentAccessToken(ctx)
+	if err != nil {
+		return responseBody, err
+	}
+
+	reauthenticated := false
+
+	for attempt := 1; ; attempt++ {
+
+		if err := c.circuitBreaker.rejectIfOpen(); err != nil {
+			return responseBody, fmt.Errorf("message=%s url=%s error=%w", "request not sent", endpoint.String(), err)
+		}
+
+		if err := c.rateLimiter.wait(ctx); err != nil {
+			return responseBody, fmt.Errorf("message=%s url=%s error=%w", "cancelled while waiting for rate limit", endpoint.String(), err)
+		}
+
+		// the probe is only claimed once the request can be sent, as a probe waiting for the rate limit
+		// would turn every other request away while the breaker is half open
+		probe, err := c.circuitBreaker.allow()
+		if err != nil {
+			return responseBody, fmt.Errorf("message=%s url=%s error=%w", "request not sent", endpoint.String(), err)
+		}
+
+		requestEndpoint := *endpoint
+		responseBody, err = executeRequest[T](ctx, c, http.MethodGet, &requestEndpoint, queryParams, c.generateHeaders(accessToken), nil)
+
+		if ctx.Err() != nil {
+			c.circuitBreaker.abandon(probe)
+		} else {
+			c.circuitBreaker.record(probe, isUpstreamFailure(err))
+		}
+
+		if err == nil {
+			return responseBody, nil
+		}
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized && !reauthenticated {
+			reauthenticated = true
+			attempt--
+			accessToken, err = c.reauthenticate(ctx, accessToken)
+			if err != nil {
+				return responseBody, err
+			}
+			continue
+		}
+
+		if apiErr != nil && apiErr.StatusCode == http.StatusTooManyRequests {
+			c.rateLimiter.drain()
+		}
+
+		if !c.retryPolicy.shouldRetry(ctx, http.MethodGet, attempt, err) {
+			return responseBody, err
+		}
+
+		if !sleepBeforeRetry(ctx, c.retryPolicy.delay(attempt, err)) {
+			return responseBody, err
+		}
 	}
 }
 
-func getHelixAccessToken(ctx context.Context) (responseBody TokenResponse, err error) {
+func (c *Client) getHelixAccessToken(ctx context.Context) (responseBody TokenResponse, err error) {
 
-	endpoint, err := url.Parse(getHelixAuthEndpoint)
+	endpoint, err := url.Parse(c.authHost)
 	if err != nil {
 		return responseBody, err
 	}
 
+	endpoint.Path = path.Join(endpoint.Path, HelixTokenEndpoint)
+
 	headers := map[string]string{
 		constants.ContentTypeHeaderKey: constants.ContentTypeFormURLEndcoded,
 	}
 
 	params := url.Values{}
-	params.Set("client_id", ClientID)
-	params.Set("client_secret", ClientSecret)
+	params.Set("client_id", c.clientID)
+	params.Set("client_secret", c.clientSecret)
 	params.Set("grant_type", "client_credentials")
 
 	body := strings.NewReader(params.Encode())
 
-	return executeRequest[TokenResponse](ctx, http.MethodPost, endpoint, nil, headers, body)
+	return executeRequest[TokenResponse](ctx, c, http.MethodPost, endpoint, nil, headers, body)
 }
 
 func executeRequest[T ClientResponseModels](
-	ctx context.Context, method string, endpoint *url.URL, queryParams, headers map[string]string, body io.Reader,
+	ctx context.Context, c *Client, method string, endpoint *url.URL, queryParams url.Values, headers map[string]string, body io.Reader,
 ) (responseBody T, err error) {
 
 	if endpoint == nil {
@@ -130,8 +472,8 @@ func executeRequest[T ClientResponseModels](
 
 	query := endpoint.Query()
 
-	for paramName, queryValue := range queryParams {
-		query.Set(paramName, queryValue)
+	for paramName, queryValues := range queryParams {
+		query[paramName] = queryValues
 	}
 
 	endpoint.RawQuery = query.Encode()
@@ -146,9 +488,9 @@ func executeRequest[T ClientResponseModels](
 		req.Header.Set(headerName, headerValue)
 	}
 
-	response, err := helixClient.Do(req)
+	response, err := c.httpClient.Do(req)
 	if err != nil {
-		return responseBody, fmt.Errorf("message=%s url=%s error=%v", "failed to execute http request", endpoint.String(), err)
+		return responseBody, &TransportError{URL: endpoint.String(), Err: err}
 	}
 
 	defer func() {
@@ -157,9 +499,10 @@ func executeRequest[T ClientResponseModels](
 		}
 	}()
 
+	c.rateLimiter.observe(response.Header)
+
 	if response.StatusCode != http.StatusOK {
-		return responseBody,
-			fmt.Errorf("message=%s url=%s status_code=%d", "received unexpected status code", endpoint.String(), response.StatusCode)
+		return responseBody, c.newAPIError(endpoint.String(), response)
 	}
 
 	responseBuffer, err := io.ReadAll(response.Body)
@@ -175,15 +518,19 @@ func executeRequest[T ClientResponseModels](
 
 type TokenResponse struct {
 	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+type UserInfo struct {
+	ID              string `json:"id"`
+	Login           string `json:"login"`
+	DisplayName     string `json:"display_name"`
+	ProfileImageURL string `json:"profile_image_url"`
 }
 
 type UsersResponseBody struct {
-	Data []struct {
-		ID              string `json:"id"`
-		Login           string `json:"login"`
-		DisplayName     string `json:"display_name"`
-		ProfileImageURL string `json:"profile_image_url"`
-	} `json:"data"`
+	Data []UserInfo `json:"data"`
 }
 
 type VideoInfo struct {
@@ -217,5 +564,7 @@ type VideosResponseBody struct {
 type ClientResponseModels interface {
 	TokenResponse |
 		UsersResponseBody |
-		VideosResponseBody
+		VideosResponseBody |
+		ClipsResponseBody |
+		StreamsResponseBody
 }
@@ -2,16 +2,25 @@ package helixclient_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 	"ttv-statistics/helixclient"
 	"ttv-statistics/testutil"
 )
 
 func TestGetUserData(t *testing.T) {
+	t.Parallel()
+
 	server := httptest.NewServer(testutil.StubServerMux())
 	defer server.Close()
-	helixclient.HelixHost = server.URL
+	client := helixclient.NewClient(helixclient.WithHelixHost(server.URL))
 
 	type testCase struct {
 		name            string
@@ -49,7 +58,7 @@ func TestGetUserData(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			resp, err := helixclient.GetUserData(context.Background(), tc.userName)
+			resp, err := client.GetUserData(context.Background(), tc.userName)
 			if tc.expectError && err == nil {
 				t.Errorf("expected error but got none")
 			}
@@ -64,14 +73,17 @@ func TestGetUserData(t *testing.T) {
 }
 
 func TestGetStreamerFirstNVideoStatistics(t *testing.T) {
+	t.Parallel()
+
 	server := httptest.NewServer(testutil.StubServerMux())
 	defer server.Close()
-	helixclient.HelixHost = server.URL
+	client := helixclient.NewClient(helixclient.WithHelixHost(server.URL))
 
 	type testCase struct {
 		name        string
 		userID      string
 		n           int
+		filter      helixclient.VideoFilter
 		expectError bool
 		expectedLen int
 	}
@@ -84,6 +96,36 @@ func TestGetStreamerFirstNVideoStatistics(t *testing.T) {
 			expectError: false,
 			expectedLen: 3,
 		},
+		{
+			name:        "N above the page size follows the cursor",
+			userID:      testutil.PaginatedUserID,
+			n:           230,
+			expectError: false,
+			expectedLen: 230,
+		},
+		{
+			name:        "N above the available videos returns all videos",
+			userID:      testutil.PaginatedUserID,
+			n:           400,
+			expectError: false,
+			expectedLen: testutil.PaginatedVideoCount,
+		},
+		{
+			name:        "Type filter is sent to helix",
+			userID:      "good_user",
+			n:           3,
+			filter:      helixclient.VideoFilter{Type: helixclient.VideoTypeHighlight},
+			expectError: false,
+			expectedLen: 1,
+		},
+		{
+			name:        "Invalid sort returns error",
+			userID:      "good_user",
+			n:           3,
+			filter:      helixclient.VideoFilter{Sort: "oldest"},
+			expectError: true,
+			expectedLen: 0,
+		},
 		{
 			name:        "Invalid userID returns error",
 			userID:      "invalid_user",
@@ -95,7 +137,7 @@ func TestGetStreamerFirstNVideoStatistics(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			resp, err := helixclient.GetStreamerFirstNVideoStatistics(context.Background(), tc.userID, tc.n)
+			resp, err := client.GetStreamerFirstNVideoStatistics(context.Background(), tc.userID, tc.n, tc.filter)
 			if tc.expectError && err == nil {
 				t.Errorf("expected error but got none")
 			}
@@ -108,3 +150,394 @@ func TestGetStreamerFirstNVideoStatistics(t *testing.T) {
 		})
 	}
 }
+
+func TestGetStreamerVideosInWindow(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(testutil.StubServerMux())
+	defer server.Close()
+	client := helixclient.NewClient(helixclient.WithHelixHost(server.URL))
+
+	// paginated video i was created i hours before PaginatedVideosNewest
+	videoCreatedAt := func(i int) time.Time {
+		return testutil.PaginatedVideosNewest.Add(-time.Duration(i) * time.Hour)
+	}
+
+	type testCase struct {
+		name          string
+		window        helixclient.VideoWindow
+		n             int
+		filter        helixclient.VideoFilter
+		expectError   bool
+		expectedLen   int
+		expectedFirst string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "Window spanning a page boundary",
+			window:        helixclient.VideoWindow{Since: videoCreatedAt(129), Until: videoCreatedAt(90)},
+			expectedLen:   40,
+			expectedFirst: "v90",
+		},
+		{
+			name:          "Window capped at N videos",
+			window:        helixclient.VideoWindow{Since: videoCreatedAt(129), Until: videoCreatedAt(90)},
+			n:             5,
+			expectedLen:   5,
+			expectedFirst: "v90",
+		},
+		{
+			name:          "Open ended window until the channel runs out of videos",
+			window:        helixclient.VideoWindow{Until: videoCreatedAt(200)},
+			expectedLen:   testutil.PaginatedVideoCount - 200,
+			expectedFirst: "v200",
+		},
+		{
+			name:          "Zero window without N returns every video",
+			expectedLen:   testutil.PaginatedVideoCount,
+			expectedFirst: "v0",
+		},
+		{
+			name:        "Window before the oldest video",
+			window:      helixclient.VideoWindow{Since: videoCreatedAt(500), Until: videoCreatedAt(400)},
+			expectedLen: 0,
+		},
+		{
+			name:        "Since after until returns error",
+			window:      helixclient.VideoWindow{Since: videoCreatedAt(1), Until: videoCreatedAt(2)},
+			expectError: true,
+		},
+		{
+			name:        "Non time sort returns error",
+			window:      helixclient.VideoWindow{Since: videoCreatedAt(2)},
+			filter:      helixclient.VideoFilter{Sort: helixclient.VideoSortViews},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := client.GetStreamerVideosInWindow(context.Background(), testutil.PaginatedUserID, tc.window, tc.n, tc.filter)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(resp.Data) != tc.expectedLen {
+				t.Fatalf("expected %d video data entries, got %d", tc.expectedLen, len(resp.Data))
+			}
+			if tc.expectedLen > 0 && resp.Data[0].ID != tc.expectedFirst {
+				t.Errorf("expected first video %s, got %s", tc.expectedFirst, resp.Data[0].ID)
+			}
+		})
+	}
+}
+
+func TestGetStreamerFirstNVideoStatisticsCancelledContext(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(testutil.StubServerMux())
+	defer server.Close()
+	client := helixclient.NewClient(helixclient.WithHelixHost(server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.GetStreamerFirstNVideoStatistics(ctx, testutil.PaginatedUserID, 250, helixclient.VideoFilter{})
+	if err == nil {
+		t.Errorf("expected error but got none")
+	}
+}
+
+func newCountingStubServer(counter *atomic.Int32, path string) *httptest.Server {
+	mux := testutil.StubServerMux()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == path {
+			counter.Add(1)
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func TestAccessTokenRefresh(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name                  string
+		authenticate          bool
+		accessToken           string
+		elapsed               time.Duration
+		expectedTokenRequests int32
+	}
+
+	testCases := []testCase{
+		{
+			name:                  "Valid token is reused",
+			authenticate:          true,
+			elapsed:               time.Minute,
+			expectedTokenRequests: 1,
+		},
+		{
+			name:                  "Token near expiry is refreshed proactively",
+			authenticate:          true,
+			elapsed:               time.Minute * 58,
+			expectedTokenRequests: 2,
+		},
+		{
+			name:                  "Rejected token is refreshed and the request replayed",
+			accessToken:           "revoked-token",
+			expectedTokenRequests: 1,
+		},
+		{
+			name:                  "Missing token is obtained after a 401",
+			expectedTokenRequests: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var tokenRequests atomic.Int32
+			server := newCountingStubServer(&tokenRequests, helixclient.HelixTokenEndpoint)
+			defer server.Close()
+
+			now := time.Now()
+			client := helixclient.NewClient(
+				helixclient.WithHelixHost(server.URL),
+				helixclient.WithAuthHost(server.URL),
+				helixclient.WithCredentials(testutil.StubClientID, testutil.StubClientSecret),
+				helixclient.WithClock(func() time.Time { return now }),
+			)
+
+			if tc.authenticate {
+				if err := client.Authenticate(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else {
+				helixclient.SetAccessToken(client, tc.accessToken, now.Add(time.Hour))
+			}
+
+			now = now.Add(tc.elapsed)
+
+			resp, err := client.GetUserData(context.Background(), testutil.AuthorisedUserName)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(resp.Data) != 1 {
+				t.Errorf("expected 1 user data entry, got %d", len(resp.Data))
+			}
+			if got := tokenRequests.Load(); got != tc.expectedTokenRequests {
+				t.Errorf("expected %d token requests, got %d", tc.expectedTokenRequests, got)
+			}
+		})
+	}
+}
+
+func TestRateLimitedRequestIsReplayedAfterReset(t *testing.T) {
+	t.Parallel()
+
+	var userRequests atomic.Int32
+	mux := testutil.StubServerMux()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == helixclient.HelixUsersEndpoint && userRequests.Add(1) == 1 {
+			w.Header().Set("Ratelimit-Limit", "800")
+			w.Header().Set("Ratelimit-Remaining", "0")
+			w.Header().Set("Ratelimit-Reset", strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := helixclient.NewClient(helixclient.WithHelixHost(server.URL))
+
+	resp, err := client.GetUserData(context.Background(), "good_user")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Data) != 1 {
+		t.Errorf("expected 1 user data entry, got %d", len(resp.Data))
+	}
+	if got := userRequests.Load(); got != 2 {
+		t.Errorf("expected 2 user requests, got %d", got)
+	}
+}
+
+func TestRateLimitedRequestDrainsBucketWithoutRetries(t *testing.T) {
+	t.Parallel()
+
+	var userRequests atomic.Int32
+	mux := testutil.StubServerMux()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == helixclient.HelixUsersEndpoint && userRequests.Add(1) == 1 {
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	// a fixed clock never refills the bucket, so once it is drained every request waits
+	now := time.Now()
+	client := helixclient.NewClient(
+		helixclient.WithHelixHost(server.URL),
+		helixclient.WithClock(func() time.Time { return now }),
+		helixclient.WithRetryPolicy(helixclient.RetryPolicy{MaxAttempts: 1}),
+	)
+
+	if _, err := client.GetUserData(context.Background(), "good_user"); err == nil {
+		t.Fatalf("expected error but got none")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	if _, err := client.GetUserData(ctx, "good_user"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the next request to wait for the rate limit, got %v", err)
+	}
+	if got := userRequests.Load(); got != 1 {
+		t.Errorf("expected 1 user request, got %d", got)
+	}
+}
+
+func TestRateLimitWaitRespectsContext(t *testing.T) {
+	t.Parallel()
+
+	mux := testutil.StubServerMux()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Ratelimit-Remaining", "0")
+		w.Header().Set("Ratelimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := helixclient.NewClient(helixclient.WithHelixHost(server.URL))
+
+	if _, err := client.GetUserData(context.Background(), "good_user"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	if _, err := client.GetUserData(ctx, "good_user"); err == nil {
+		t.Errorf("expected error but got none")
+	}
+}
+
+func TestLookupUsers(t *testing.T) {
+	t.Parallel()
+
+	bulkLogins := []string{}
+	for i := range 250 {
+		bulkLogins = append(bulkLogins, fmt.Sprintf("%s%d", testutil.BulkUserPrefix, i))
+	}
+
+	type testCase struct {
+		name                  string
+		logins                []string
+		userIDs               []string
+		expectError           bool
+		expectedFoundLogins   []string
+		expectedMissingLogins []string
+		expectedFoundIDs      []string
+		expectedMissingIDs    []string
+		expectedRequests      int32
+	}
+
+	testCases := []testCase{
+		{
+			name:                  "Logins are matched case insensitively and missing logins are reported",
+			logins:                []string{"Good_User", "second_user", "unknown_user", "good_user"},
+			expectedFoundLogins:   []string{"good_user", testutil.SecondUserName},
+			expectedMissingLogins: []string{"unknown_user"},
+			expectedRequests:      1,
+		},
+		{
+			name:                  "Logins and IDs share a request",
+			logins:                []string{"good_user"},
+			userIDs:               []string{testutil.SecondUserName, "99999"},
+			expectedFoundLogins:   []string{"good_user", testutil.SecondUserName},
+			expectedFoundIDs:      []string{testutil.SecondUserName},
+			expectedMissingIDs:    []string{"99999"},
+			expectedMissingLogins: []string{},
+			expectedRequests:      1,
+		},
+		{
+			name:                  "More than 100 logins are split into chunks",
+			logins:                append(slices.Clone(bulkLogins), "unknown_user"),
+			userIDs:               []string{testutil.BulkUserIDPrefix + "7"},
+			expectedFoundLogins:   bulkLogins,
+			expectedMissingLogins: []string{"unknown_user"},
+			expectedFoundIDs:      []string{testutil.BulkUserIDPrefix + "7"},
+			expectedRequests:      3,
+		},
+		{
+			name:             "Nothing to look up",
+			expectedRequests: 0,
+		},
+		{
+			name:             "A failed chunk returns error",
+			logins:           []string{"good_user", "bad_user"},
+			expectError:      true,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var userRequests atomic.Int32
+			server := newCountingStubServer(&userRequests, helixclient.HelixUsersEndpoint)
+			defer server.Close()
+
+			client := helixclient.NewClient(helixclient.WithHelixHost(server.URL))
+
+			lookups, err := client.LookupUsers(context.Background(), tc.logins, tc.userIDs)
+			if got := userRequests.Load(); got != tc.expectedRequests {
+				t.Errorf("expected %d user requests, got %d", tc.expectedRequests, got)
+			}
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, login := range tc.expectedFoundLogins {
+				if lookup, ok := lookups.ByLogin[login]; !ok || !lookup.Found || lookup.Login != login {
+					t.Errorf("expected login %s to be found, got %+v", login, lookup)
+				}
+			}
+			for _, login := range tc.expectedMissingLogins {
+				if lookup, ok := lookups.ByLogin[login]; !ok || lookup.Found {
+					t.Errorf("expected login %s to be reported as not found, got %+v (present=%t)", login, lookup, ok)
+				}
+			}
+			for _, userID := range tc.expectedFoundIDs {
+				if lookup, ok := lookups.ByID[userID]; !ok || !lookup.Found || lookup.ID != userID {
+					t.Errorf("expected id %s to be found, got %+v", userID, lookup)
+				}
+			}
+			for _, userID := range tc.expectedMissingIDs {
+				if lookup, ok := lookups.ByID[userID]; !ok || lookup.Found {
+					t.Errorf("expected id %s to be reported as not found, got %+v (present=%t)", userID, lookup, ok)
+				}
+			}
+			if len(lookups.ByLogin) != len(tc.expectedFoundLogins)+len(tc.expectedMissingLogins) {
+				t.Errorf("expected %d logins, got %d", len(tc.expectedFoundLogins)+len(tc.expectedMissingLogins), len(lookups.ByLogin))
+			}
+		})
+	}
+}
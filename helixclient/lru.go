@@ -0,0 +1,92 @@
+package helixclient
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruCache is a size bounded, least recently used cache whose entries expire after a fixed TTL.
+type lruCache[K comparable, V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+	order      *list.List
+	entries    map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+func newLRUCache[K comparable, V any](ttl time.Duration, maxEntries int, now func() time.Time) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        now,
+		order:      list.New(),
+		entries:    map[K]*list.Element{},
+	}
+}
+
+func (c *lruCache[K, V]) enabled() bool {
+	return c.ttl > 0 && c.maxEntries > 0
+}
+
+func (c *lruCache[K, V]) get(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return value, false
+	}
+
+	entry := element.Value.(*lruEntry[K, V])
+	if !c.now().Before(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return value, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (c *lruCache[K, V]) set(key K, value V) {
+
+	if !c.enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry[K, V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+func (c *lruCache[K, V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
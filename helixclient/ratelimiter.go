@@ -0,0 +1,135 @@
+package helixclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	rateLimitLimitHeaderKey     string = "Ratelimit-Limit"
+	rateLimitRemainingHeaderKey string = "Ratelimit-Remaining"
+	rateLimitResetHeaderKey     string = "Ratelimit-Reset"
+
+	// defaultRateLimit is the helix app access token bucket size, replaced by Ratelimit-Limit once known
+	defaultRateLimit    int           = 800
+	rateLimitRefillTime time.Duration = time.Minute
+)
+
+// rateLimiter is a token bucket mirroring the helix rate limit bucket. The bucket refills
+// continuously and is re-synchronised with the Ratelimit-* headers of every helix response.
+type rateLimiter struct {
+	mu         sync.Mutex
+	capacity   float64
+	tokens     float64
+	lastRefill time.Time
+	resetAt    time.Time
+	now        func() time.Time
+}
+
+func newRateLimiter(capacity int, now func() time.Time) *rateLimiter {
+	return &rateLimiter{
+		capacity:   float64(capacity),
+		tokens:     float64(capacity),
+		lastRefill: now(),
+		now:        now,
+	}
+}
+
+// wait blocks until a token is available or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+
+	for {
+		delay := l.reserve()
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long to wait before trying again.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.refill(now)
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+
+	if now.Before(l.resetAt) {
+		return l.resetAt.Sub(now)
+	}
+
+	return time.Duration((1 - l.tokens) / l.refillRate() * float64(time.Second))
+}
+
+// observe synchronises the bucket with the rate limit headers of a helix response.
+func (l *rateLimiter) observe(header http.Header) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.refill(now)
+
+	if limit, err := strconv.Atoi(header.Get(rateLimitLimitHeaderKey)); err == nil && limit > 0 {
+		l.capacity = float64(limit)
+	}
+
+	if remaining, err := strconv.Atoi(header.Get(rateLimitRemainingHeaderKey)); err == nil && remaining >= 0 {
+		l.tokens = min(float64(remaining), l.capacity)
+	}
+
+	if reset, err := strconv.ParseInt(header.Get(rateLimitResetHeaderKey), 10, 64); err == nil {
+		l.resetAt = time.Unix(reset, 0)
+	}
+}
+
+// drain empties the bucket after helix has rejected a request with 429 Too Many Requests.
+func (l *rateLimiter) drain() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = 0
+	l.lastRefill = l.now()
+}
+
+func (l *rateLimiter) refill(now time.Time) {
+
+	if !l.resetAt.IsZero() && !now.Before(l.resetAt) {
+		// helix resets the bucket to full at the reset time
+		l.tokens = l.capacity
+		l.resetAt = time.Time{}
+		l.lastRefill = now
+		return
+	}
+
+	if now.Before(l.resetAt) && l.tokens < 1 {
+		// the bucket is exhausted, nothing is available until the reset time
+		l.lastRefill = now
+		return
+	}
+
+	elapsed := now.Sub(l.lastRefill).Seconds()
+	if elapsed > 0 {
+		l.tokens = min(l.capacity, l.tokens+elapsed*l.refillRate())
+		l.lastRefill = now
+	}
+}
+
+func (l *rateLimiter) refillRate() float64 {
+	return l.capacity / rateLimitRefillTime.Seconds()
+}
@@ -0,0 +1,135 @@
+package helixclient
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+	"ttv-statistics/constants"
+)
+
+const (
+	DefaultRetryMaxAttempts int           = 4
+	DefaultRetryBaseDelay   time.Duration = time.Millisecond * 250
+	DefaultRetryMaxDelay    time.Duration = time.Second * 5
+	DefaultRetryJitter      float64       = 0.5
+)
+
+var (
+	DefaultRetryableStatusCodes = []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
+
+// RetryPolicy decides whether and when a failed helix request is attempted again. Only idempotent
+// requests are retried, after transport errors or one of RetryableStatusCodes.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first, 1 disables retries
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubling for each retry after it up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter is the fraction, between 0 and 1, of each delay that is randomised so that clients
+	// failing together do not retry together
+	Jitter               float64
+	RetryableStatusCodes []int
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:          DefaultRetryMaxAttempts,
+		BaseDelay:            DefaultRetryBaseDelay,
+		MaxDelay:             DefaultRetryMaxDelay,
+		Jitter:               DefaultRetryJitter,
+		RetryableStatusCodes: slices.Clone(DefaultRetryableStatusCodes),
+	}
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// shouldRetry reports whether a request that failed with err on the given attempt, counting from 1,
+// may be attempted again.
+func (p RetryPolicy) shouldRetry(ctx context.Context, method string, attempt int, err error) bool {
+
+	if attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return slices.Contains(p.RetryableStatusCodes, apiErr.StatusCode)
+	}
+
+	var transportErr *TransportError
+	return errors.As(err, &transportErr)
+}
+
+// delay returns how long to wait before retrying after the given attempt. A Retry-After sent by helix
+// takes precedence over the exponential backoff.
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+
+	jitter := min(max(p.Jitter, 0), 1)
+	return delay - time.Duration(jitter*rand.Float64()*float64(delay))
+}
+
+// sleepBeforeRetry waits for delay, returning false without waiting if the context is done or its
+// deadline would pass before the retry could be made.
+func sleepBeforeRetry(ctx context.Context, delay time.Duration) bool {
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return false
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given either as a number of seconds or as an HTTP date.
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+
+	value := header.Get(constants.RetryAfterHeaderKey)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+
+	return 0
+}
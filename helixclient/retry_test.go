@@ -0,0 +1,204 @@
+package helixclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/testutil"
+)
+
+// newFlakyStubServer serves the stub helix API, failing the first failures requests to path with
+// statusCode, or by dropping the connection when statusCode is 0.
+func newFlakyStubServer(counter *atomic.Int32, path string, failures int32, statusCode int, header http.Header) *httptest.Server {
+	mux := testutil.StubServerMux()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == path && counter.Add(1) <= failures {
+			if statusCode == 0 {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					conn.Close()
+				}
+				return
+			}
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			http.Error(w, http.StatusText(statusCode), statusCode)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	fastRetries := helixclient.RetryPolicy{
+		MaxAttempts:          3,
+		BaseDelay:            time.Millisecond,
+		MaxDelay:             time.Millisecond * 10,
+		Jitter:               0.5,
+		RetryableStatusCodes: helixclient.DefaultRetryableStatusCodes,
+	}
+
+	type testCase struct {
+		name             string
+		policy           helixclient.RetryPolicy
+		failures         int32
+		statusCode       int
+		header           http.Header
+		timeout          time.Duration
+		expectError      bool
+		expectedRequests int32
+		minimumElapsed   time.Duration
+	}
+
+	testCases := []testCase{
+		{
+			name:             "Service unavailable blip is retried",
+			policy:           fastRetries,
+			failures:         1,
+			statusCode:       http.StatusServiceUnavailable,
+			expectError:      false,
+			expectedRequests: 2,
+		},
+		{
+			name:             "Bad gateway is retried",
+			policy:           fastRetries,
+			failures:         2,
+			statusCode:       http.StatusBadGateway,
+			expectError:      false,
+			expectedRequests: 3,
+		},
+		{
+			name:             "Dropped connection is retried",
+			policy:           fastRetries,
+			failures:         1,
+			statusCode:       0,
+			expectError:      false,
+			expectedRequests: 2,
+		},
+		{
+			name:             "Retries stop after max attempts",
+			policy:           fastRetries,
+			failures:         5,
+			statusCode:       http.StatusGatewayTimeout,
+			expectError:      true,
+			expectedRequests: 3,
+		},
+		{
+			name:             "Internal server error is not retried",
+			policy:           fastRetries,
+			failures:         1,
+			statusCode:       http.StatusInternalServerError,
+			expectError:      true,
+			expectedRequests: 1,
+		},
+		{
+			name:             "Not found is not retried",
+			policy:           fastRetries,
+			failures:         1,
+			statusCode:       http.StatusNotFound,
+			expectError:      true,
+			expectedRequests: 1,
+		},
+		{
+			name:             "Single attempt policy disables retries",
+			policy:           helixclient.RetryPolicy{MaxAttempts: 1},
+			failures:         1,
+			statusCode:       http.StatusServiceUnavailable,
+			expectError:      true,
+			expectedRequests: 1,
+		},
+		{
+			name:             "Retry-After is honoured",
+			policy:           fastRetries,
+			failures:         1,
+			statusCode:       http.StatusServiceUnavailable,
+			header:           http.Header{"Retry-After": {"1"}},
+			expectError:      false,
+			expectedRequests: 2,
+			minimumElapsed:   time.Second,
+		},
+		{
+			name:             "Retry is abandoned when it would pass the context deadline",
+			policy:           fastRetries,
+			failures:         1,
+			statusCode:       http.StatusServiceUnavailable,
+			header:           http.Header{"Retry-After": {"60"}},
+			timeout:          time.Second * 5,
+			expectError:      true,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var userRequests atomic.Int32
+			server := newFlakyStubServer(&userRequests, helixclient.HelixUsersEndpoint, tc.failures, tc.statusCode, tc.header)
+			defer server.Close()
+
+			client := helixclient.NewClient(
+				helixclient.WithHelixHost(server.URL),
+				helixclient.WithRetryPolicy(tc.policy),
+			)
+
+			ctx := context.Background()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			resp, err := client.GetUserData(ctx, "good_user")
+			elapsed := time.Since(start)
+
+			if tc.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tc.expectError && len(resp.Data) != 1 {
+				t.Errorf("expected 1 user data entry, got %d", len(resp.Data))
+			}
+			if got := userRequests.Load(); got != tc.expectedRequests {
+				t.Errorf("expected %d user requests, got %d", tc.expectedRequests, got)
+			}
+			if elapsed < tc.minimumElapsed {
+				t.Errorf("expected request to take at least %s, took %s", tc.minimumElapsed, elapsed)
+			}
+			if tc.timeout > 0 && elapsed >= tc.timeout {
+				t.Errorf("expected request to give up before the deadline, took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestTokenRequestIsNotRetried(t *testing.T) {
+	t.Parallel()
+
+	var tokenRequests atomic.Int32
+	server := newFlakyStubServer(&tokenRequests, helixclient.HelixTokenEndpoint, 1, http.StatusServiceUnavailable, nil)
+	defer server.Close()
+
+	client := helixclient.NewClient(
+		helixclient.WithHelixHost(server.URL),
+		helixclient.WithAuthHost(server.URL),
+		helixclient.WithCredentials(testutil.StubClientID, testutil.StubClientSecret),
+	)
+
+	if err := client.Authenticate(context.Background()); err == nil {
+		t.Errorf("expected error but got none")
+	}
+	if got := tokenRequests.Load(); got != 1 {
+		t.Errorf("expected 1 token request, got %d", got)
+	}
+}
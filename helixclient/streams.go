@@ -0,0 +1,88 @@
+package helixclient
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HelixStreamsEndpoint string = "/streams"
+)
+
+// StreamInfo describes a live stream. Helix only returns streams that are currently live.
+type StreamInfo struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
+	UserLogin    string    `json:"user_login"`
+	UserName     string    `json:"user_name"`
+	GameID       string    `json:"game_id"`
+	GameName     string    `json:"game_name"`
+	Type         string    `json:"type"`
+	Title        string    `json:"title"`
+	Tags         []string  `json:"tags"`
+	ViewerCount  int       `json:"viewer_count"`
+	StartedAt    time.Time `json:"started_at"`
+	Language     string    `json:"language"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	IsMature     bool      `json:"is_mature"`
+}
+
+type StreamsResponseBody struct {
+	Data       []StreamInfo      `json:"data"`
+	Pagination map[string]string `json:"pagination"`
+}
+
+// GetStreams returns the live streams of the given users, issuing one helix request per
+// helixMaxPageSize users. Users who are not live have no stream in the response. Concurrent requests
+// for the same users share one upstream request.
+func (c *Client) GetStreams(ctx context.Context, userIDs []string) (responseBody StreamsResponseBody, err error) {
+
+	userIDs = slices.Compact(slices.Sorted(slices.Values(userIDs)))
+	if len(userIDs) == 0 {
+		return StreamsResponseBody{Data: []StreamInfo{}}, nil
+	}
+
+	responseBody, shared, err := c.streamFlights.do(ctx, strings.Join(userIDs, ","), func(ctx context.Context) (StreamsResponseBody, error) {
+		return c.getStreams(ctx, userIDs)
+	})
+
+	if shared {
+		responseBody.Data = slices.Clone(responseBody.Data)
+	}
+
+	return responseBody, err
+}
+
+func (c *Client) getStreams(ctx context.Context, userIDs []string) (responseBody StreamsResponseBody, err error) {
+
+	endpoint, err := url.Parse(c.helixHost)
+	if err != nil {
+		return responseBody, err
+	}
+
+	endpoint.Path = path.Join(endpoint.Path, HelixStreamsEndpoint)
+
+	responseBody.Data = []StreamInfo{}
+
+	for chunk := range slices.Chunk(userIDs, helixMaxPageSize) {
+
+		queryParams := url.Values{
+			helixUserIDURLParam: chunk,
+			helixFirstURLParam:  {strconv.Itoa(helixMaxPageSize)},
+		}
+
+		page, err := executeAuthorisedRequest[StreamsResponseBody](ctx, c, endpoint, queryParams)
+		if err != nil {
+			return StreamsResponseBody{}, err
+		}
+
+		responseBody.Data = append(responseBody.Data, page.Data...)
+	}
+
+	return responseBody, nil
+}
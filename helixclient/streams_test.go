@@ -0,0 +1,82 @@
+package helixclient_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/testutil"
+)
+
+func TestGetStreams(t *testing.T) {
+	t.Parallel()
+
+	bulkUserIDs := []string{}
+	for i := range 150 {
+		bulkUserIDs = append(bulkUserIDs, fmt.Sprintf("%s%d", testutil.BulkUserIDPrefix, i))
+	}
+
+	type testCase struct {
+		name             string
+		userIDs          []string
+		expectedLive     []string
+		expectedRequests int32
+	}
+
+	testCases := []testCase{
+		{
+			name:             "Only live streamers have a stream",
+			userIDs:          []string{"good_user", testutil.SecondUserName, "good_user"},
+			expectedLive:     []string{"good_user"},
+			expectedRequests: 1,
+		},
+		{
+			name:             "No users makes no request",
+			userIDs:          []string{},
+			expectedLive:     []string{},
+			expectedRequests: 0,
+		},
+		{
+			name:             "More than 100 users are split into chunks",
+			userIDs:          bulkUserIDs,
+			expectedLive:     bulkUserIDs,
+			expectedRequests: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var streamRequests atomic.Int32
+			server := newCountingStubServer(&streamRequests, helixclient.HelixStreamsEndpoint)
+			defer server.Close()
+
+			client := helixclient.NewClient(helixclient.WithHelixHost(server.URL))
+
+			resp, err := client.GetStreams(context.Background(), tc.userIDs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			live := map[string]bool{}
+			for _, stream := range resp.Data {
+				live[stream.UserID] = true
+			}
+
+			if len(live) != len(tc.expectedLive) {
+				t.Errorf("expected %d live streams, got %d", len(tc.expectedLive), len(live))
+			}
+			for _, userID := range tc.expectedLive {
+				if !live[userID] {
+					t.Errorf("expected %s to be live", userID)
+				}
+			}
+
+			if got := streamRequests.Load(); got != tc.expectedRequests {
+				t.Errorf("expected %d stream requests, got %d", tc.expectedRequests, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package helixclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	helixIDURLParam string = "id"
+
+	// helixMaxUsersPerRequest is the combined number of login and id params helix /users accepts
+	helixMaxUsersPerRequest int = 100
+	userLookupParallelism   int = 4
+)
+
+// UserLookup is the outcome of looking up a single login or user ID. Found is false when helix
+// has no user for the requested login or ID.
+type UserLookup struct {
+	UserInfo
+	Found bool `json:"found"`
+}
+
+type UserLookups struct {
+	// ByLogin holds an entry for every requested login, and for the login of every user found by ID
+	ByLogin map[string]UserLookup
+	// ByID holds an entry for every requested user ID
+	ByID map[string]UserLookup
+}
+
+type userLookupParam struct {
+	name  string
+	value string
+}
+
+// LookupUsers resolves any number of logins and user IDs, issuing up to userLookupParallelism helix
+// requests of at most helixMaxUsersPerRequest params concurrently. Logins are matched case insensitively
+// and keyed in lower case. The first failed request cancels the others and its error is returned.
+func (c *Client) LookupUsers(ctx context.Context, logins, userIDs []string) (UserLookups, error) {
+
+	lookups := UserLookups{
+		ByLogin: map[string]UserLookup{},
+		ByID:    map[string]UserLookup{},
+	}
+
+	params := []userLookupParam{}
+
+	for _, login := range logins {
+		login = strings.ToLower(strings.TrimSpace(login))
+		if _, ok := lookups.ByLogin[login]; login == "" || ok {
+			continue
+		}
+		lookups.ByLogin[login] = UserLookup{UserInfo: UserInfo{Login: login}}
+		params = append(params, userLookupParam{name: helixLoginURLParam, value: login})
+	}
+
+	for _, userID := range userIDs {
+		userID = strings.TrimSpace(userID)
+		if _, ok := lookups.ByID[userID]; userID == "" || ok {
+			continue
+		}
+		lookups.ByID[userID] = UserLookup{UserInfo: UserInfo{ID: userID}}
+		params = append(params, userLookupParam{name: helixIDURLParam, value: userID})
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		errOnce   sync.Once
+		firstErr  error
+		semaphore = make(chan struct{}, userLookupParallelism)
+	)
+
+	for chunk := range slices.Chunk(params, helixMaxUsersPerRequest) {
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				return
+			}
+
+			responseBody, err := c.getUsers(ctx, chunk)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for _, user := range responseBody.Data {
+				lookup := UserLookup{UserInfo: user, Found: true}
+				if _, ok := lookups.ByID[user.ID]; ok {
+					lookups.ByID[user.ID] = lookup
+				}
+				lookups.ByLogin[strings.ToLower(user.Login)] = lookup
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return UserLookups{}, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return UserLookups{}, fmt.Errorf("message=%s error=%w", "user lookup cancelled", err)
+	}
+
+	return lookups, nil
+}
+
+func (c *Client) getUsers(ctx context.Context, params []userLookupParam) (responseBody UsersResponseBody, err error) {
+
+	endpoint, err := url.Parse(c.helixHost)
+	if err != nil {
+		return responseBody, err
+	}
+
+	endpoint.Path = path.Join(endpoint.Path, HelixUsersEndpoint)
+
+	queryParams := url.Values{}
+	for _, param := range params {
+		queryParams.Add(param.name, param.value)
+	}
+
+	return executeAuthorisedRequest[UsersResponseBody](ctx, c, endpoint, queryParams)
+}
@@ -0,0 +1,127 @@
+package helixclient
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	VideoTypeAll       string = "all"
+	VideoTypeArchive   string = "archive"
+	VideoTypeHighlight string = "highlight"
+	VideoTypeUpload    string = "upload"
+
+	VideoPeriodAll   string = "all"
+	VideoPeriodDay   string = "day"
+	VideoPeriodWeek  string = "week"
+	VideoPeriodMonth string = "month"
+
+	VideoSortTime     string = "time"
+	VideoSortTrending string = "trending"
+	VideoSortViews    string = "views"
+
+	helixTypeURLParam   string = "type"
+	helixPeriodURLParam string = "period"
+	helixSortURLParam   string = "sort"
+)
+
+var (
+	VideoTypes   = []string{VideoTypeAll, VideoTypeArchive, VideoTypeHighlight, VideoTypeUpload}
+	VideoPeriods = []string{VideoPeriodAll, VideoPeriodDay, VideoPeriodWeek, VideoPeriodMonth}
+	VideoSorts   = []string{VideoSortTime, VideoSortTrending, VideoSortViews}
+)
+
+// VideoFilter narrows the videos returned by helix /videos. Empty fields are not sent,
+// leaving helix to apply its own defaults.
+type VideoFilter struct {
+	Type   string `json:"type"`
+	Period string `json:"period"`
+	Sort   string `json:"sort"`
+}
+
+func (f VideoFilter) Validate() error {
+
+	if f.Type != "" && !slices.Contains(VideoTypes, f.Type) {
+		return fmt.Errorf("type must be one of %s", strings.Join(VideoTypes, ", "))
+	}
+
+	if f.Period != "" && !slices.Contains(VideoPeriods, f.Period) {
+		return fmt.Errorf("period must be one of %s", strings.Join(VideoPeriods, ", "))
+	}
+
+	if f.Sort != "" && !slices.Contains(VideoSorts, f.Sort) {
+		return fmt.Errorf("sort must be one of %s", strings.Join(VideoSorts, ", "))
+	}
+
+	return nil
+}
+
+// WithDefaults returns the filter with unset fields replaced by the helix defaults.
+func (f VideoFilter) WithDefaults() VideoFilter {
+
+	if f.Type == "" {
+		f.Type = VideoTypeAll
+	}
+
+	if f.Period == "" {
+		f.Period = VideoPeriodAll
+	}
+
+	if f.Sort == "" {
+		f.Sort = VideoSortTime
+	}
+
+	return f
+}
+
+func (f VideoFilter) queryParams() url.Values {
+
+	queryParams := url.Values{}
+
+	if f.Type != "" {
+		queryParams.Set(helixTypeURLParam, f.Type)
+	}
+
+	if f.Period != "" {
+		queryParams.Set(helixPeriodURLParam, f.Period)
+	}
+
+	if f.Sort != "" {
+		queryParams.Set(helixSortURLParam, f.Sort)
+	}
+
+	return queryParams
+}
+
+// VideoWindow bounds the creation time of videos. A zero Since or Until leaves that side of the
+// window open.
+type VideoWindow struct {
+	Since time.Time `json:"since,omitzero"`
+	Until time.Time `json:"until,omitzero"`
+}
+
+func (w VideoWindow) Validate() error {
+
+	if !w.Since.IsZero() && !w.Until.IsZero() && w.Since.After(w.Until) {
+		return fmt.Errorf("since must not be after until")
+	}
+
+	return nil
+}
+
+func (w VideoWindow) IsZero() bool {
+	return w.Since.IsZero() && w.Until.IsZero()
+}
+
+// Contains reports whether t falls within the window, inclusive of both bounds.
+func (w VideoWindow) Contains(t time.Time) bool {
+	return !w.Before(t) && (w.Until.IsZero() || !t.After(w.Until))
+}
+
+// Before reports whether t is earlier than the start of the window.
+func (w VideoWindow) Before(t time.Time) bool {
+	return !w.Since.IsZero() && t.Before(w.Since)
+}
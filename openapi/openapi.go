@@ -0,0 +1,202 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Version = "3.0.3"
+
+	InPath  = "path"
+	InQuery = "query"
+
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeArray   = "array"
+	TypeObject  = "object"
+
+	FormatDateTime = "date-time"
+)
+
+// ErrMissingParameter matches, with errors.Is, the ParameterError of a required parameter that was not
+// provided.
+var ErrMissingParameter = errors.New("missing required parameter")
+
+// Document is an OpenAPI 3 document, limited to the parts of the specification this API uses.
+type Document struct {
+	OpenAPI string               `json:"openapi"`
+	Info    Info                 `json:"info"`
+	Paths   map[string]*PathItem `json:"paths"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type PathItem struct {
+	Get *Operation `json:"get,omitempty"`
+}
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary"`
+	Description string              `json:"description,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// ParameterError reports a parameter of a request that does not satisfy its Parameter. Use errors.As
+// to obtain it.
+type ParameterError struct {
+	Name string
+	In   string
+	Err  error
+}
+
+func (e *ParameterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParameterError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateRequest checks the path and query parameters of r against the operation's parameters,
+// returning a *ParameterError for the first that is missing or invalid. Path parameters are read
+// with r.PathValue, so r must have been routed by an http.ServeMux.
+func (o Operation) ValidateRequest(r *http.Request) error {
+
+	query := r.URL.Query()
+
+	for _, parameter := range o.Parameters {
+
+		var value string
+		switch parameter.In {
+		case InPath:
+			value = r.PathValue(parameter.Name)
+		case InQuery:
+			value = query.Get(parameter.Name)
+		default:
+			continue
+		}
+
+		if value == "" {
+			if parameter.Required {
+				return &ParameterError{Name: parameter.Name, In: parameter.In, Err: ErrMissingParameter}
+			}
+			continue
+		}
+
+		if err := parameter.Validate(value); err != nil {
+			return &ParameterError{Name: parameter.Name, In: parameter.In, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// Validate checks a single value of the parameter against its schema.
+func (p Parameter) Validate(value string) error {
+
+	if p.Schema == nil {
+		return nil
+	}
+
+	return p.Schema.validateValue(p.Name, value)
+}
+
+func (s *Schema) validateValue(name, value string) error {
+
+	switch s.Type {
+	case TypeInteger:
+		integer, err := strconv.Atoi(value)
+		if err != nil {
+			if s.Minimum != nil && s.Maximum != nil {
+				return fmt.Errorf("%s must be an integer between %d and %d", name, *s.Minimum, *s.Maximum)
+			}
+			return fmt.Errorf("%s must be a valid integer", name)
+		}
+		if (s.Minimum != nil && integer < *s.Minimum) || (s.Maximum != nil && integer > *s.Maximum) {
+			switch {
+			case s.Minimum != nil && s.Maximum != nil:
+				return fmt.Errorf("%s must be an integer between %d and %d", name, *s.Minimum, *s.Maximum)
+			case s.Minimum != nil:
+				return fmt.Errorf("%s must be at least %d", name, *s.Minimum)
+			default:
+				return fmt.Errorf("%s must be at most %d", name, *s.Maximum)
+			}
+		}
+	case TypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%s must be a valid number", name)
+		}
+	case TypeBoolean:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false", name)
+		}
+	case TypeString:
+		if s.Format == FormatDateTime {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				return fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+			}
+		}
+	}
+
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, value) {
+		return fmt.Errorf("%s must be one of %s", name, strings.Join(s.Enum, ", "))
+	}
+
+	return nil
+}
+
+// Int returns a pointer to v, for the Minimum and Maximum of a Schema.
+func Int(v int) *int {
+	return &v
+}
@@ -0,0 +1,140 @@
+package openapi_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+	"ttv-statistics/openapi"
+)
+
+func TestValidateRequest(t *testing.T) {
+
+	operation := openapi.Operation{
+		Parameters: []openapi.Parameter{
+			{Name: "username", In: openapi.InPath, Required: true, Schema: &openapi.Schema{Type: openapi.TypeString}},
+			{Name: "N", In: openapi.InQuery, Required: true, Schema: &openapi.Schema{Type: openapi.TypeInteger}},
+			{Name: "precision", In: openapi.InQuery, Schema: &openapi.Schema{Type: openapi.TypeInteger, Minimum: openapi.Int(0), Maximum: openapi.Int(10)}},
+			{Name: "sort", In: openapi.InQuery, Schema: &openapi.Schema{Type: openapi.TypeString, Enum: []string{"time", "views"}}},
+			{Name: "since", In: openapi.InQuery, Schema: &openapi.Schema{Type: openapi.TypeString, Format: openapi.FormatDateTime}},
+		},
+	}
+
+	type testCase struct {
+		name            string
+		target          string
+		expectedMissing bool
+		expectedError   string
+	}
+
+	testCases := []testCase{
+		{
+			name:   "Valid request",
+			target: "/streamer/good_user?N=3&precision=2&sort=views&since=2025-06-01T00:00:00Z",
+		},
+		{
+			name:            "Missing required query parameter",
+			target:          "/streamer/good_user",
+			expectedMissing: true,
+			expectedError:   "missing required parameter",
+		},
+		{
+			name:          "Invalid integer",
+			target:        "/streamer/good_user?N=three",
+			expectedError: "N must be a valid integer",
+		},
+		{
+			name:          "Integer out of range",
+			target:        "/streamer/good_user?N=3&precision=11",
+			expectedError: "precision must be an integer between 0 and 10",
+		},
+		{
+			name:          "Value outside the enum",
+			target:        "/streamer/good_user?N=3&sort=random",
+			expectedError: "sort must be one of time, views",
+		},
+		{
+			name:          "Invalid timestamp",
+			target:        "/streamer/good_user?N=3&since=yesterday",
+			expectedError: "since must be an RFC 3339 timestamp",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			var err error
+			mux := http.NewServeMux()
+			mux.HandleFunc("/streamer/{username}", func(w http.ResponseWriter, r *http.Request) {
+				err = operation.ValidateRequest(r)
+			})
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var parameterErr *openapi.ParameterError
+			if !errors.As(err, &parameterErr) {
+				t.Fatalf("expected a *ParameterError, got %v", err)
+			}
+			if err.Error() != tc.expectedError {
+				t.Errorf("\nwant %q\n got %q", tc.expectedError, err.Error())
+			}
+			if missing := errors.Is(err, openapi.ErrMissingParameter); missing != tc.expectedMissing {
+				t.Errorf("expected missing %t, got %t", tc.expectedMissing, missing)
+			}
+		})
+	}
+}
+
+type embedded struct {
+	Rank int `json:"rank"`
+}
+
+type stubBody struct {
+	embedded
+	Name      string         `json:"name"`
+	Tags      []string       `json:"tags"`
+	Counts    map[string]int `json:"counts"`
+	CreatedAt time.Time      `json:"created_at,omitzero"`
+	Score     float64        `json:"score,omitempty"`
+	Custom    time.Duration  `json:"custom"`
+	Ignored   string         `json:"-"`
+	private   string
+}
+
+func TestSchemaOf(t *testing.T) {
+
+	durationSchema := &openapi.Schema{Type: openapi.TypeString}
+	schema := openapi.SchemaOf(reflect.TypeFor[stubBody](), map[reflect.Type]*openapi.Schema{
+		reflect.TypeFor[time.Duration](): durationSchema,
+	})
+
+	expected := &openapi.Schema{
+		Type: openapi.TypeObject,
+		Properties: map[string]*openapi.Schema{
+			"rank":       {Type: openapi.TypeInteger},
+			"name":       {Type: openapi.TypeString},
+			"tags":       {Type: openapi.TypeArray, Items: &openapi.Schema{Type: openapi.TypeString}},
+			"counts":     {Type: openapi.TypeObject, AdditionalProperties: &openapi.Schema{Type: openapi.TypeInteger}},
+			"created_at": {Type: openapi.TypeString, Format: openapi.FormatDateTime},
+			"score":      {Type: openapi.TypeNumber},
+			"custom":     durationSchema,
+		},
+		Required: []string{"rank", "name", "tags", "counts", "custom"},
+	}
+
+	slices.Sort(schema.Required)
+	slices.Sort(expected.Required)
+
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("unexpected schema: \nwant: %+v, \n got: %+v", expected, schema)
+	}
+}
@@ -0,0 +1,80 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaOf describes the JSON encoding of values of type t. Types with a custom JSON encoding, such
+// as those implementing json.Marshaler, are described by the schema given for them in overrides.
+func SchemaOf(t reflect.Type, overrides map[reflect.Type]*Schema) *Schema {
+
+	if schema, ok := overrides[t]; ok {
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return SchemaOf(t.Elem(), overrides)
+	case reflect.String:
+		return &Schema{Type: TypeString}
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: TypeInteger}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: TypeNumber}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: TypeArray, Items: SchemaOf(t.Elem(), overrides)}
+	case reflect.Map:
+		return &Schema{Type: TypeObject, AdditionalProperties: SchemaOf(t.Elem(), overrides)}
+	case reflect.Struct:
+		if t == reflect.TypeFor[time.Time]() {
+			return &Schema{Type: TypeString, Format: FormatDateTime}
+		}
+		schema := &Schema{Type: TypeObject, Properties: map[string]*Schema{}}
+		addProperties(schema, t, overrides)
+		return schema
+	default:
+		return &Schema{}
+	}
+}
+
+// addProperties adds the exported fields of the struct type t to schema, flattening embedded structs
+// the way encoding/json does. Fields that may be omitted are not required.
+func addProperties(schema *Schema, t reflect.Type, overrides map[reflect.Type]*Schema) {
+
+	for i := range t.NumField() {
+
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			if _, ok := overrides[field.Type]; !ok {
+				addProperties(schema, field.Type, overrides)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = SchemaOf(field.Type, overrides)
+
+		if !strings.Contains(options, "omitempty") && !strings.Contains(options, "omitzero") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package statstools
+
+import (
+	"cmp"
+	"slices"
+	"time"
+	"ttv-statistics/helixclient"
+)
+
+type TopClip struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	CreatorName string    `json:"creator_name"`
+	ViewCount   int       `json:"view_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type TopClipper struct {
+	CreatorID    string `json:"creator_id"`
+	CreatorName  string `json:"creator_name"`
+	ClipCount    int    `json:"clip_count"`
+	ViewCountSum int    `json:"view_count_sum"`
+}
+
+// VODClipStatistics counts the clips made from one of the streamer's past broadcasts.
+type VODClipStatistics struct {
+	VideoID      string `json:"video_id"`
+	Title        string `json:"title"`
+	ClipCount    int    `json:"clip_count"`
+	ViewCountSum int    `json:"view_count_sum"`
+}
+
+type ClipStatistics struct {
+	ClipCount    int          `json:"clip_count"`
+	ViewCountSum int          `json:"view_count_sum"`
+	TopClips     []TopClip    `json:"top_clips"`
+	TopClippers  []TopClipper `json:"top_clippers"`
+
+	// ClipsPerVOD is the average number of clips made from each of VODs
+	ClipsPerVOD float64             `json:"clips_per_vod"`
+	VODs        []VODClipStatistics `json:"vods"`
+
+	Window helixclient.VideoWindow `json:"window,omitzero"`
+}
+
+// AggregateStreamerClipStatistics summarises clips, reporting the top most viewed clips and the top
+// clippers by number of clips made. Clips are attributed to vods, the streamer's past broadcasts, by
+// their video ID; clips of VODs not in vods, or whose VOD has been deleted, count only towards the
+// totals.
+func AggregateStreamerClipStatistics(clips []helixclient.ClipInfo, vods []helixclient.VideoInfo, top int) ClipStatistics {
+
+	top = max(top, 0)
+
+	statistics := ClipStatistics{
+		ClipCount:   len(clips),
+		TopClips:    []TopClip{},
+		TopClippers: []TopClipper{},
+		VODs:        make([]VODClipStatistics, 0, len(vods)),
+	}
+
+	clippers := map[string]*TopClipper{}
+	vodIndex := map[string]int{}
+
+	for _, vod := range vods {
+		vodIndex[vod.ID] = len(statistics.VODs)
+		statistics.VODs = append(statistics.VODs, VODClipStatistics{VideoID: vod.ID, Title: vod.Title})
+	}
+
+	clippedVODClips := 0
+
+	for _, clip := range clips {
+
+		statistics.ViewCountSum += clip.ViewCount
+
+		clipper, ok := clippers[clip.CreatorID]
+		if !ok {
+			clipper = &TopClipper{CreatorID: clip.CreatorID, CreatorName: clip.CreatorName}
+			clippers[clip.CreatorID] = clipper
+		}
+		clipper.ClipCount++
+		clipper.ViewCountSum += clip.ViewCount
+
+		if i, ok := vodIndex[clip.VideoID]; ok && clip.VideoID != "" {
+			statistics.VODs[i].ClipCount++
+			statistics.VODs[i].ViewCountSum += clip.ViewCount
+			clippedVODClips++
+		}
+	}
+
+	if len(vods) > 0 {
+		statistics.ClipsPerVOD = float64(clippedVODClips) / float64(len(vods))
+	}
+
+	sortedClips := slices.Clone(clips)
+	slices.SortStableFunc(sortedClips, func(a, b helixclient.ClipInfo) int {
+		return cmp.Or(cmp.Compare(b.ViewCount, a.ViewCount), cmp.Compare(a.ID, b.ID))
+	})
+
+	for _, clip := range sortedClips[:min(top, len(sortedClips))] {
+		statistics.TopClips = append(statistics.TopClips, TopClip{
+			ID:          clip.ID,
+			Title:       clip.Title,
+			URL:         clip.URL,
+			CreatorName: clip.CreatorName,
+			ViewCount:   clip.ViewCount,
+			CreatedAt:   clip.CreatedAt,
+		})
+	}
+
+	for _, clipper := range clippers {
+		statistics.TopClippers = append(statistics.TopClippers, *clipper)
+	}
+
+	slices.SortFunc(statistics.TopClippers, func(a, b TopClipper) int {
+		return cmp.Or(
+			cmp.Compare(b.ClipCount, a.ClipCount),
+			cmp.Compare(b.ViewCountSum, a.ViewCountSum),
+			cmp.Compare(a.CreatorName, b.CreatorName),
+			cmp.Compare(a.CreatorID, b.CreatorID),
+		)
+	})
+
+	statistics.TopClippers = statistics.TopClippers[:min(top, len(statistics.TopClippers))]
+
+	return statistics
+}
@@ -0,0 +1,116 @@
+package statstools_test
+
+import (
+	"reflect"
+	"testing"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/statstools"
+)
+
+func TestAggregateStreamerClipStatistics(t *testing.T) {
+
+	clip := func(id, creator, videoID string, viewCount int) helixclient.ClipInfo {
+		return helixclient.ClipInfo{ID: id, Title: id, CreatorID: creator, CreatorName: creator, VideoID: videoID, ViewCount: viewCount}
+	}
+
+	topClip := func(id, creator string, viewCount int) statstools.TopClip {
+		return statstools.TopClip{ID: id, Title: id, CreatorName: creator, ViewCount: viewCount}
+	}
+
+	vods := []helixclient.VideoInfo{
+		{ID: "v1", Title: "VOD 1"},
+		{ID: "v2", Title: "VOD 2"},
+	}
+
+	type testCase struct {
+		name     string
+		clips    []helixclient.ClipInfo
+		vods     []helixclient.VideoInfo
+		top      int
+		expected statstools.ClipStatistics
+	}
+
+	testCases := []testCase{
+		{
+			name: "No clips",
+			vods: vods,
+			top:  5,
+			expected: statstools.ClipStatistics{
+				TopClips:    []statstools.TopClip{},
+				TopClippers: []statstools.TopClipper{},
+				VODs: []statstools.VODClipStatistics{
+					{VideoID: "v1", Title: "VOD 1"},
+					{VideoID: "v2", Title: "VOD 2"},
+				},
+			},
+		},
+		{
+			name: "Clips attributed to VODs and clippers",
+			clips: []helixclient.ClipInfo{
+				clip("c1", "a", "v1", 100),
+				clip("c2", "b", "v1", 400),
+				clip("c3", "a", "", 50),
+				clip("c4", "c", "v2", 200),
+				clip("c5", "b", "v_deleted", 10),
+			},
+			vods: vods,
+			top:  2,
+			expected: statstools.ClipStatistics{
+				ClipCount:    5,
+				ViewCountSum: 760,
+				TopClips:     []statstools.TopClip{topClip("c2", "b", 400), topClip("c4", "c", 200)},
+				TopClippers: []statstools.TopClipper{
+					{CreatorID: "b", CreatorName: "b", ClipCount: 2, ViewCountSum: 410},
+					{CreatorID: "a", CreatorName: "a", ClipCount: 2, ViewCountSum: 150},
+				},
+				ClipsPerVOD: 1.5,
+				VODs: []statstools.VODClipStatistics{
+					{VideoID: "v1", Title: "VOD 1", ClipCount: 2, ViewCountSum: 500},
+					{VideoID: "v2", Title: "VOD 2", ClipCount: 1, ViewCountSum: 200},
+				},
+			},
+		},
+		{
+			name:  "Ties are broken by clip ID and creator name",
+			clips: []helixclient.ClipInfo{clip("c2", "b", "", 100), clip("c1", "a", "", 100)},
+			top:   5,
+			expected: statstools.ClipStatistics{
+				ClipCount:    2,
+				ViewCountSum: 200,
+				TopClips:     []statstools.TopClip{topClip("c1", "a", 100), topClip("c2", "b", 100)},
+				TopClippers: []statstools.TopClipper{
+					{CreatorID: "a", CreatorName: "a", ClipCount: 1, ViewCountSum: 100},
+					{CreatorID: "b", CreatorName: "b", ClipCount: 1, ViewCountSum: 100},
+				},
+				VODs: []statstools.VODClipStatistics{},
+			},
+		},
+		{
+			name: "Clippers sharing a name are ordered by ID",
+			clips: []helixclient.ClipInfo{
+				{ID: "c1", Title: "c1", CreatorID: "2", CreatorName: "sam", ViewCount: 100},
+				{ID: "c2", Title: "c2", CreatorID: "1", CreatorName: "sam", ViewCount: 100},
+			},
+			top: 5,
+			expected: statstools.ClipStatistics{
+				ClipCount:    2,
+				ViewCountSum: 200,
+				TopClips:     []statstools.TopClip{topClip("c1", "sam", 100), topClip("c2", "sam", 100)},
+				TopClippers: []statstools.TopClipper{
+					{CreatorID: "1", CreatorName: "sam", ClipCount: 1, ViewCountSum: 100},
+					{CreatorID: "2", CreatorName: "sam", ClipCount: 1, ViewCountSum: 100},
+				},
+				VODs: []statstools.VODClipStatistics{},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := statstools.AggregateStreamerClipStatistics(tc.clips, tc.vods, tc.top)
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("unexpected result: \nwant: %+v, \n got: %+v", tc.expected, result)
+			}
+		})
+	}
+}
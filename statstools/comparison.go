@@ -0,0 +1,143 @@
+package statstools
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type ComparisonMetric string
+
+const (
+	ComparisonMetricViewCountSum     ComparisonMetric = "view_count_sum"
+	ComparisonMetricViewCountAvg     ComparisonMetric = "view_count_avg"
+	ComparisonMetricViewPerMinuteAvg ComparisonMetric = "view_per_minute_avg"
+)
+
+var (
+	ComparisonMetrics = []ComparisonMetric{
+		ComparisonMetricViewCountSum,
+		ComparisonMetricViewCountAvg,
+		ComparisonMetricViewPerMinuteAvg,
+	}
+)
+
+func ParseComparisonMetric(metric string) (ComparisonMetric, error) {
+
+	for _, comparisonMetric := range ComparisonMetrics {
+		if string(comparisonMetric) == metric {
+			return comparisonMetric, nil
+		}
+	}
+
+	metrics := make([]string, 0, len(ComparisonMetrics))
+	for _, comparisonMetric := range ComparisonMetrics {
+		metrics = append(metrics, string(comparisonMetric))
+	}
+
+	return "", fmt.Errorf("rank_by must be one of %s", strings.Join(metrics, ", "))
+}
+
+// MetricValues holds the compared metrics, using the floating point averages so small differences
+// between streamers are not hidden by truncation.
+type MetricValues struct {
+	ViewCountSum     float64 `json:"view_count_sum"`
+	ViewCountAvg     float64 `json:"view_count_avg"`
+	ViewPerMinuteAvg float64 `json:"view_per_minute_avg"`
+}
+
+func (v MetricValues) get(metric ComparisonMetric) float64 {
+
+	switch metric {
+	case ComparisonMetricViewCountSum:
+		return v.ViewCountSum
+	case ComparisonMetricViewPerMinuteAvg:
+		return v.ViewPerMinuteAvg
+	default:
+		return v.ViewCountAvg
+	}
+}
+
+type StreamerStatistics struct {
+	Login       string               `json:"login"`
+	UserID      string               `json:"user_id"`
+	DisplayName string               `json:"display_name"`
+	Statistics  LastNVideoStatistics `json:"statistics"`
+}
+
+func (s StreamerStatistics) metricValues() MetricValues {
+	return MetricValues{
+		ViewCountSum:     float64(s.Statistics.ViewCountSum),
+		ViewCountAvg:     s.Statistics.ViewCountAvgFloat,
+		ViewPerMinuteAvg: s.Statistics.ViewPerMinuteAvgFloat,
+	}
+}
+
+type RankedStreamer struct {
+	Rank int `json:"rank"`
+	StreamerStatistics
+	DeltaFromMedian MetricValues `json:"delta_from_median"`
+}
+
+type Comparison struct {
+	RankedBy    ComparisonMetric `json:"ranked_by"`
+	GroupMedian MetricValues     `json:"group_median"`
+	Streamers   []RankedStreamer `json:"streamers"`
+}
+
+// CompareStreamers ranks streamers by rankBy in descending order, breaking ties by login, and reports
+// each streamer's difference from the group median of every compared metric.
+func CompareStreamers(streamers []StreamerStatistics, rankBy ComparisonMetric) Comparison {
+
+	comparison := Comparison{
+		RankedBy:  rankBy,
+		Streamers: make([]RankedStreamer, 0, len(streamers)),
+	}
+
+	if len(streamers) == 0 {
+		return comparison
+	}
+
+	viewCountSums := make([]float64, 0, len(streamers))
+	viewCountAvgs := make([]float64, 0, len(streamers))
+	viewPerMinuteAvgs := make([]float64, 0, len(streamers))
+
+	for _, streamer := range streamers {
+		values := streamer.metricValues()
+		viewCountSums = append(viewCountSums, values.ViewCountSum)
+		viewCountAvgs = append(viewCountAvgs, values.ViewCountAvg)
+		viewPerMinuteAvgs = append(viewPerMinuteAvgs, values.ViewPerMinuteAvg)
+	}
+
+	comparison.GroupMedian = MetricValues{
+		ViewCountSum:     Summarise(viewCountSums).Median,
+		ViewCountAvg:     Summarise(viewCountAvgs).Median,
+		ViewPerMinuteAvg: Summarise(viewPerMinuteAvgs).Median,
+	}
+
+	for _, streamer := range streamers {
+		values := streamer.metricValues()
+		comparison.Streamers = append(comparison.Streamers, RankedStreamer{
+			StreamerStatistics: streamer,
+			DeltaFromMedian: MetricValues{
+				ViewCountSum:     values.ViewCountSum - comparison.GroupMedian.ViewCountSum,
+				ViewCountAvg:     values.ViewCountAvg - comparison.GroupMedian.ViewCountAvg,
+				ViewPerMinuteAvg: values.ViewPerMinuteAvg - comparison.GroupMedian.ViewPerMinuteAvg,
+			},
+		})
+	}
+
+	slices.SortFunc(comparison.Streamers, func(a, b RankedStreamer) int {
+		return cmp.Or(
+			cmp.Compare(b.metricValues().get(rankBy), a.metricValues().get(rankBy)),
+			cmp.Compare(a.Login, b.Login),
+		)
+	})
+
+	for i := range comparison.Streamers {
+		comparison.Streamers[i].Rank = i + 1
+	}
+
+	return comparison
+}
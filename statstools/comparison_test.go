@@ -0,0 +1,97 @@
+package statstools_test
+
+import (
+	"testing"
+	"ttv-statistics/statstools"
+)
+
+func TestCompareStreamers(t *testing.T) {
+
+	streamer := func(login string, viewCountSum int, viewCountAvg, viewPerMinuteAvg float64) statstools.StreamerStatistics {
+		return statstools.StreamerStatistics{
+			Login: login,
+			Statistics: statstools.LastNVideoStatistics{
+				ViewCountSum:          viewCountSum,
+				ViewCountAvgFloat:     viewCountAvg,
+				ViewPerMinuteAvgFloat: viewPerMinuteAvg,
+			},
+		}
+	}
+
+	type expectedRank struct {
+		login string
+		delta statstools.MetricValues
+	}
+
+	type testCase struct {
+		name           string
+		inputs         []statstools.StreamerStatistics
+		rankBy         statstools.ComparisonMetric
+		expectedMedian statstools.MetricValues
+		expectedRanks  []expectedRank
+	}
+
+	testCases := []testCase{
+		{
+			name:          "No streamers",
+			inputs:        []statstools.StreamerStatistics{},
+			rankBy:        statstools.ComparisonMetricViewCountAvg,
+			expectedRanks: []expectedRank{},
+		},
+		{
+			name: "Ranked by view count sum",
+			inputs: []statstools.StreamerStatistics{
+				streamer("a", 100, 10, 1),
+				streamer("b", 300, 30, 2),
+				streamer("c", 200, 50, 3),
+			},
+			rankBy:         statstools.ComparisonMetricViewCountSum,
+			expectedMedian: statstools.MetricValues{ViewCountSum: 200, ViewCountAvg: 30, ViewPerMinuteAvg: 2},
+			expectedRanks: []expectedRank{
+				{login: "b", delta: statstools.MetricValues{ViewCountSum: 100, ViewCountAvg: 0, ViewPerMinuteAvg: 0}},
+				{login: "c", delta: statstools.MetricValues{ViewCountSum: 0, ViewCountAvg: 20, ViewPerMinuteAvg: 1}},
+				{login: "a", delta: statstools.MetricValues{ViewCountSum: -100, ViewCountAvg: -20, ViewPerMinuteAvg: -1}},
+			},
+		},
+		{
+			name: "Ties are broken by login",
+			inputs: []statstools.StreamerStatistics{
+				streamer("z", 100, 10, 1),
+				streamer("y", 100, 10, 1),
+			},
+			rankBy:         statstools.ComparisonMetricViewPerMinuteAvg,
+			expectedMedian: statstools.MetricValues{ViewCountSum: 100, ViewCountAvg: 10, ViewPerMinuteAvg: 1},
+			expectedRanks: []expectedRank{
+				{login: "y"},
+				{login: "z"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			result := statstools.CompareStreamers(tc.inputs, tc.rankBy)
+
+			if result.RankedBy != tc.rankBy {
+				t.Errorf("unexpected ranked by: want: %s, got: %s", tc.rankBy, result.RankedBy)
+			}
+
+			if result.GroupMedian != tc.expectedMedian {
+				t.Errorf("unexpected group median: want: %+v, got: %+v", tc.expectedMedian, result.GroupMedian)
+			}
+
+			if len(result.Streamers) != len(tc.expectedRanks) {
+				t.Fatalf("expected %d streamers, got %d", len(tc.expectedRanks), len(result.Streamers))
+			}
+
+			for i, expected := range tc.expectedRanks {
+				got := result.Streamers[i]
+				if got.Rank != i+1 || got.Login != expected.login || got.DeltaFromMedian != expected.delta {
+					t.Errorf("unexpected streamer at rank %d: want: %+v, got: rank=%d login=%s delta=%+v",
+						i+1, expected, got.Rank, got.Login, got.DeltaFromMedian)
+				}
+			}
+		})
+	}
+}
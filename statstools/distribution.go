@@ -0,0 +1,83 @@
+package statstools
+
+import (
+	"math"
+	"slices"
+)
+
+type Distribution struct {
+	ViewCount       DistributionSummary `json:"view_count"`
+	DurationSeconds DistributionSummary `json:"duration_seconds"`
+}
+
+type DistributionSummary struct {
+	Min                    float64 `json:"min"`
+	Max                    float64 `json:"max"`
+	Median                 float64 `json:"median"`
+	P25                    float64 `json:"p25"`
+	P75                    float64 `json:"p75"`
+	P90                    float64 `json:"p90"`
+	StdDev                 float64 `json:"std_dev"`
+	CoefficientOfVariation float64 `json:"coefficient_of_variation"`
+}
+
+// Summarise describes the spread of values. Percentiles are linearly interpolated between the closest
+// ranks and the standard deviation is the population standard deviation, as the videos are the whole
+// set being described rather than a sample of it.
+func Summarise(values []float64) (summary DistributionSummary) {
+
+	if len(values) == 0 {
+		return summary
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	summary.Min = sorted[0]
+	summary.Max = sorted[len(sorted)-1]
+	summary.Median = percentile(sorted, 50)
+	summary.P25 = percentile(sorted, 25)
+	summary.P75 = percentile(sorted, 75)
+	summary.P90 = percentile(sorted, 90)
+
+	sum := 0.0
+	for _, value := range sorted {
+		sum += value
+	}
+	mean := sum / float64(len(sorted))
+
+	squaredDeviations := 0.0
+	for _, value := range sorted {
+		squaredDeviations += (value - mean) * (value - mean)
+	}
+
+	summary.StdDev = math.Sqrt(squaredDeviations / float64(len(sorted)))
+	if mean != 0 {
+		summary.CoefficientOfVariation = summary.StdDev / mean
+	}
+
+	return summary
+}
+
+// percentile expects sorted to be non-empty and in ascending order
+func percentile(sorted []float64, p int) float64 {
+
+	rank := float64(p*(len(sorted)-1)) / 100
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+func (s DistributionSummary) withPrecision(precision int) DistributionSummary {
+	return DistributionSummary{
+		Min:                    round(s.Min, precision),
+		Max:                    round(s.Max, precision),
+		Median:                 round(s.Median, precision),
+		P25:                    round(s.P25, precision),
+		P75:                    round(s.P75, precision),
+		P90:                    round(s.P90, precision),
+		StdDev:                 round(s.StdDev, precision),
+		CoefficientOfVariation: round(s.CoefficientOfVariation, precision),
+	}
+}
@@ -0,0 +1,72 @@
+package statstools_test
+
+import (
+	"testing"
+	"ttv-statistics/statstools"
+)
+
+func TestSummarise(t *testing.T) {
+
+	type testCase struct {
+		name     string
+		inputs   []float64
+		expected statstools.DistributionSummary
+	}
+
+	testCases := []testCase{
+		{
+			name:     "No values",
+			inputs:   []float64{},
+			expected: statstools.DistributionSummary{},
+		},
+		{
+			name:   "Single value",
+			inputs: []float64{42},
+			expected: statstools.DistributionSummary{
+				Min: 42, Max: 42, Median: 42, P25: 42, P75: 42, P90: 42,
+			},
+		},
+		{
+			name:   "Even number of unsorted values",
+			inputs: []float64{40, 10, 30, 20},
+			expected: statstools.DistributionSummary{
+				Min: 10, Max: 40, Median: 25, P25: 17.5, P75: 32.5, P90: 37,
+				StdDev: 11.180339887498949, CoefficientOfVariation: 0.4472135954999579,
+			},
+		},
+		{
+			name:   "A single viral video skews the spread but not the median",
+			inputs: []float64{100, 100, 100, 100, 10000},
+			expected: statstools.DistributionSummary{
+				Min: 100, Max: 10000, Median: 100, P25: 100, P75: 100, P90: 6040,
+				StdDev: 3960, CoefficientOfVariation: 1.9038461538461537,
+			},
+		},
+		{
+			name:   "All zeros",
+			inputs: []float64{0, 0, 0},
+			expected: statstools.DistributionSummary{
+				Min: 0, Max: 0, Median: 0, P25: 0, P75: 0, P90: 0,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			inputs := append([]float64{}, tc.inputs...)
+			result := statstools.Summarise(tc.inputs)
+
+			if !distributionSummaryApproxEqual(result, tc.expected) {
+				t.Errorf("unexpected result: \nwant: %+v, \n got: %+v", tc.expected, result)
+			}
+
+			for i := range inputs {
+				if inputs[i] != tc.inputs[i] {
+					t.Errorf("inputs were modified: want: %v, got: %v", inputs, tc.inputs)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package statstools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type DurationFormat string
+
+const (
+	DurationFormatNanoseconds DurationFormat = "ns"
+	DurationFormatSeconds     DurationFormat = "seconds"
+	DurationFormatISO8601     DurationFormat = "iso8601"
+	DurationFormatGo          DurationFormat = "go"
+)
+
+var (
+	DurationFormats = []DurationFormat{
+		DurationFormatNanoseconds,
+		DurationFormatSeconds,
+		DurationFormatISO8601,
+		DurationFormatGo,
+	}
+)
+
+func ParseDurationFormat(format string) (DurationFormat, error) {
+
+	for _, durationFormat := range DurationFormats {
+		if string(durationFormat) == format {
+			return durationFormat, nil
+		}
+	}
+
+	formats := make([]string, 0, len(DurationFormats))
+	for _, durationFormat := range DurationFormats {
+		formats = append(formats, string(durationFormat))
+	}
+
+	return "", fmt.Errorf("duration_format must be one of %s", strings.Join(formats, ", "))
+}
+
+// Duration is a time.Duration encoded to JSON in the chosen Format. The zero Format encodes
+// nanoseconds, matching the encoding of a plain time.Duration.
+type Duration struct {
+	time.Duration
+	Format DurationFormat
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+
+	switch d.Format {
+	case "", DurationFormatNanoseconds:
+		return json.Marshal(int64(d.Duration))
+	case DurationFormatSeconds:
+		return json.Marshal(d.Seconds())
+	case DurationFormatISO8601:
+		return json.Marshal(formatISO8601(d.Duration))
+	case DurationFormatGo:
+		return json.Marshal(d.String())
+	default:
+		return nil, fmt.Errorf("message=%q format=%s", "unknown duration format", d.Format)
+	}
+}
+
+// UnmarshalJSON accepts nanoseconds as a JSON number, or an ISO 8601 or Go duration as a JSON string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+
+	var nanoseconds int64
+	if err := json.Unmarshal(data, &nanoseconds); err == nil {
+		*d = Duration{Duration: time.Duration(nanoseconds), Format: DurationFormatNanoseconds}
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("message=%q innermessage=%v", "duration must be a number or a string", err)
+	}
+
+	if strings.HasPrefix(value, "P") || strings.HasPrefix(value, "-P") {
+		duration, err := parseISO8601(value)
+		if err != nil {
+			return err
+		}
+		*d = Duration{Duration: duration, Format: DurationFormatISO8601}
+		return nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("message=%q innermessage=%v", "failed to parse duration", err)
+	}
+
+	*d = Duration{Duration: duration, Format: DurationFormatGo}
+	return nil
+}
+
+// formatISO8601 encodes d as an ISO 8601 duration using hours, minutes and seconds only,
+// e.g. PT26H3M4.5S, as days are ambiguous across daylight saving changes.
+func formatISO8601(d time.Duration) string {
+
+	if d == 0 {
+		return "PT0S"
+	}
+
+	var builder strings.Builder
+
+	if d < 0 {
+		builder.WriteString("-")
+		d = -d
+	}
+
+	builder.WriteString("PT")
+
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+
+	if hours > 0 {
+		builder.WriteString(strconv.FormatInt(int64(hours), 10) + "H")
+	}
+
+	if minutes > 0 {
+		builder.WriteString(strconv.FormatInt(int64(minutes), 10) + "M")
+	}
+
+	if d > 0 {
+		builder.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S")
+	}
+
+	return builder.String()
+}
+
+// parseISO8601 decodes the time part of an ISO 8601 duration, as produced by formatISO8601.
+func parseISO8601(value string) (time.Duration, error) {
+
+	invalid := fmt.Errorf("message=%q value=%q", "invalid ISO 8601 duration", value)
+
+	negative := strings.HasPrefix(value, "-")
+	remaining, found := strings.CutPrefix(strings.TrimPrefix(value, "-"), "PT")
+	if !found || remaining == "" {
+		return 0, invalid
+	}
+
+	units := map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	var duration time.Duration
+
+	for remaining != "" {
+
+		end := strings.IndexAny(remaining, "HMS")
+		if end <= 0 {
+			return 0, invalid
+		}
+
+		amount, err := strconv.ParseFloat(remaining[:end], 64)
+		if err != nil || amount < 0 {
+			return 0, invalid
+		}
+
+		duration += time.Duration(amount * float64(units[remaining[end]]))
+		remaining = remaining[end+1:]
+	}
+
+	if negative {
+		duration = -duration
+	}
+
+	return duration, nil
+}
@@ -0,0 +1,105 @@
+package statstools_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	"ttv-statistics/statstools"
+)
+
+func TestDurationMarshalJSON(t *testing.T) {
+
+	type testCase struct {
+		name     string
+		input    statstools.Duration
+		expected string
+	}
+
+	testCases := []testCase{
+		{
+			name:     "Default format is nanoseconds",
+			input:    statstools.Duration{Duration: time.Hour},
+			expected: `3600000000000`,
+		},
+		{
+			name:     "Nanoseconds",
+			input:    statstools.Duration{Duration: time.Hour, Format: statstools.DurationFormatNanoseconds},
+			expected: `3600000000000`,
+		},
+		{
+			name:     "Seconds",
+			input:    statstools.Duration{Duration: 90*time.Minute + 500*time.Millisecond, Format: statstools.DurationFormatSeconds},
+			expected: `5400.5`,
+		},
+		{
+			name:     "ISO 8601",
+			input:    statstools.Duration{Duration: 26*time.Hour + 3*time.Minute + 4500*time.Millisecond, Format: statstools.DurationFormatISO8601},
+			expected: `"PT26H3M4.5S"`,
+		},
+		{
+			name:     "ISO 8601 whole hours",
+			input:    statstools.Duration{Duration: time.Hour, Format: statstools.DurationFormatISO8601},
+			expected: `"PT1H"`,
+		},
+		{
+			name:     "ISO 8601 zero",
+			input:    statstools.Duration{Format: statstools.DurationFormatISO8601},
+			expected: `"PT0S"`,
+		},
+		{
+			name:     "Go",
+			input:    statstools.Duration{Duration: time.Hour, Format: statstools.DurationFormatGo},
+			expected: `"1h0m0s"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			result, err := json.Marshal(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(result) != tc.expected {
+				t.Errorf("unexpected result: want: %s, got: %s", tc.expected, result)
+			}
+
+			var decoded statstools.Duration
+			if err := json.Unmarshal(result, &decoded); tc.input.Format != statstools.DurationFormatSeconds && err != nil {
+				t.Fatalf("unexpected error decoding %s: %v", result, err)
+			}
+
+			if tc.input.Format != statstools.DurationFormatSeconds && decoded.Duration != tc.input.Duration {
+				t.Errorf("unexpected round trip: want: %v, got: %v", tc.input.Duration, decoded.Duration)
+			}
+		})
+	}
+}
+
+func TestDurationUnmarshalJSONInvalid(t *testing.T) {
+
+	inputs := []string{`"PT"`, `"P1D"`, `"PTxH"`, `"one hour"`, `true`}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			var decoded statstools.Duration
+			if err := json.Unmarshal([]byte(input), &decoded); err == nil {
+				t.Errorf("expected error but got none")
+			}
+		})
+	}
+}
+
+func TestParseDurationFormat(t *testing.T) {
+
+	if format, err := statstools.ParseDurationFormat("iso8601"); err != nil || format != statstools.DurationFormatISO8601 {
+		t.Errorf("unexpected result: format: %q, error: %v", format, err)
+	}
+
+	_, err := statstools.ParseDurationFormat("hours")
+	expectedError := "duration_format must be one of ns, seconds, iso8601, go"
+	if err == nil || err.Error() != expectedError {
+		t.Errorf("unexpected error: want: %v, got: %v", expectedError, err)
+	}
+}
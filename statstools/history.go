@@ -0,0 +1,231 @@
+package statstools
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// HistoryMetric names a numeric field of LastNVideoStatistics by its JSON path, e.g. view_count_sum or
+// distribution.view_count.p90.
+type HistoryMetric string
+
+type GapFill string
+
+const (
+	// GapFillNull leaves the values of buckets without snapshots null
+	GapFillNull GapFill = "null"
+	// GapFillPrevious carries the values of the last bucket with snapshots forward
+	GapFillPrevious GapFill = "previous"
+	// GapFillZero reports buckets without snapshots as zero
+	GapFillZero GapFill = "zero"
+)
+
+var (
+	GapFills = []GapFill{
+		GapFillNull,
+		GapFillPrevious,
+		GapFillZero,
+	}
+
+	historyMetricFields = numericFields(reflect.TypeFor[LastNVideoStatistics](), "", nil)
+
+	// HistoryMetrics lists every numeric field of LastNVideoStatistics, in alphabetical order
+	HistoryMetrics = slices.Sorted(maps.Keys(historyMetricFields))
+)
+
+// historyMetricField locates a metric within LastNVideoStatistics
+type historyMetricField struct {
+	index    []int
+	duration bool
+}
+
+// numericFields finds the numeric fields of t and the structs it contains, keyed by their JSON path.
+// Durations are included and reported in seconds, whatever format they are encoded in.
+func numericFields(t reflect.Type, prefix string, index []int) map[HistoryMetric]historyMetricField {
+
+	fields := map[HistoryMetric]historyMetricField{}
+
+	for i := range t.NumField() {
+
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" || name == "" {
+			continue
+		}
+
+		path := prefix + name
+		fieldIndex := append(slices.Clone(index), i)
+
+		switch {
+		case field.Type == reflect.TypeFor[Duration]():
+			fields[HistoryMetric(path)] = historyMetricField{index: fieldIndex, duration: true}
+		case field.Type == reflect.TypeFor[time.Time]():
+		case field.Type.Kind() == reflect.Struct:
+			for metric, nested := range numericFields(field.Type, path+".", fieldIndex) {
+				fields[metric] = nested
+			}
+		case field.Type.Kind() >= reflect.Int && field.Type.Kind() <= reflect.Float64:
+			fields[HistoryMetric(path)] = historyMetricField{index: fieldIndex}
+		}
+	}
+
+	return fields
+}
+
+func ParseHistoryMetric(metric string) (HistoryMetric, error) {
+
+	if _, ok := historyMetricFields[HistoryMetric(metric)]; ok {
+		return HistoryMetric(metric), nil
+	}
+
+	metrics := make([]string, 0, len(HistoryMetrics))
+	for _, historyMetric := range HistoryMetrics {
+		metrics = append(metrics, string(historyMetric))
+	}
+
+	return "", fmt.Errorf("metric must be one of %s", strings.Join(metrics, ", "))
+}
+
+func ParseGapFill(fill string) (GapFill, error) {
+
+	for _, gapFill := range GapFills {
+		if string(gapFill) == fill {
+			return gapFill, nil
+		}
+	}
+
+	fills := make([]string, 0, len(GapFills))
+	for _, gapFill := range GapFills {
+		fills = append(fills, string(gapFill))
+	}
+
+	return "", fmt.Errorf("fill must be one of %s", strings.Join(fills, ", "))
+}
+
+// Value returns the metric of statistics.
+func (m HistoryMetric) Value(statistics LastNVideoStatistics) float64 {
+
+	field, ok := historyMetricFields[m]
+	if !ok {
+		return 0
+	}
+
+	value := reflect.ValueOf(statistics).FieldByIndex(field.index)
+
+	switch {
+	case field.duration:
+		return value.Interface().(Duration).Seconds()
+	case value.CanInt():
+		return float64(value.Int())
+	case value.CanUint():
+		return float64(value.Uint())
+	default:
+		return value.Float()
+	}
+}
+
+// HistoryBucket summarises the snapshots collected in [Start, Start+bucket). A bucket without snapshots
+// has a SnapshotCount of 0, and values as chosen by the GapFill.
+type HistoryBucket struct {
+	Start         time.Time `json:"start"`
+	SnapshotCount int       `json:"snapshot_count"`
+	Min           *float64  `json:"min"`
+	Max           *float64  `json:"max"`
+	Avg           *float64  `json:"avg"`
+}
+
+// HistoryBucketCount returns the number of buckets DownsampleSnapshots divides [from, to) into.
+func HistoryBucketCount(from, to time.Time, bucket time.Duration) int {
+
+	if bucket <= 0 || !to.After(from) {
+		return 0
+	}
+
+	whole, partial := bucketsBetween(from.UTC().Truncate(bucket), to, bucket)
+	if partial {
+		whole++
+	}
+
+	return whole
+}
+
+// bucketsBetween returns the number of whole buckets from start to end, which must not be before start,
+// and whether a partial bucket follows them
+func bucketsBetween(start, end time.Time, bucket time.Duration) (int, bool) {
+
+	span := end.Sub(start)
+	if start.Add(span).Equal(end) {
+		return int(span / bucket), span%bucket != 0
+	}
+
+	// Sub saturates for spans beyond about 292 years, so those are divided in seconds
+	seconds := float64(end.Unix()-start.Unix()) + float64(end.Nanosecond()-start.Nanosecond())/float64(time.Second)
+	buckets := seconds / bucket.Seconds()
+
+	return int(min(buckets, math.MaxInt32)), buckets != math.Trunc(buckets)
+}
+
+// DownsampleSnapshots divides [from, to) into buckets of the given length and summarises metric over
+// the snapshots collected in each. Buckets are aligned to multiples of bucket since the zero time in
+// UTC, so daily buckets start at midnight UTC, weekly buckets on Monday, and the first bucket may start
+// before from. Snapshots collected outside [from, to) are ignored.
+func DownsampleSnapshots(snapshots []Snapshot, metric HistoryMetric, from, to time.Time, bucket time.Duration, fill GapFill) []HistoryBucket {
+
+	count := HistoryBucketCount(from, to, bucket)
+	start := from.UTC().Truncate(bucket)
+
+	values := make([][]float64, count)
+	for _, snapshot := range snapshots {
+		if snapshot.CollectedAt.Before(from) || !snapshot.CollectedAt.Before(to) {
+			continue
+		}
+		i, _ := bucketsBetween(start, snapshot.CollectedAt, bucket)
+		i = min(i, count-1)
+		values[i] = append(values[i], metric.Value(snapshot.Statistics))
+	}
+
+	buckets := make([]HistoryBucket, 0, count)
+	var previous HistoryBucket
+
+	// each start is stepped from the last, as i*bucket can overflow a Duration over long ranges
+	bucketStart := start
+
+	for _, bucketValues := range values {
+
+		historyBucket := HistoryBucket{
+			Start:         bucketStart,
+			SnapshotCount: len(bucketValues),
+		}
+
+		switch {
+		case len(bucketValues) > 0:
+			minValue, maxValue, sum := bucketValues[0], bucketValues[0], 0.0
+			for _, value := range bucketValues {
+				minValue = min(minValue, value)
+				maxValue = max(maxValue, value)
+				sum += value
+			}
+			avg := sum / float64(len(bucketValues))
+			historyBucket.Min, historyBucket.Max, historyBucket.Avg = &minValue, &maxValue, &avg
+		case fill == GapFillPrevious && previous.SnapshotCount > 0:
+			historyBucket.Min, historyBucket.Max, historyBucket.Avg = previous.Min, previous.Max, previous.Avg
+		case fill == GapFillZero:
+			zero := 0.0
+			historyBucket.Min, historyBucket.Max, historyBucket.Avg = &zero, &zero, &zero
+		}
+
+		buckets = append(buckets, historyBucket)
+		bucketStart = bucketStart.Add(bucket)
+
+		if historyBucket.SnapshotCount > 0 {
+			previous = historyBucket
+		}
+	}
+
+	return buckets
+}
@@ -0,0 +1,216 @@
+package statstools_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	"ttv-statistics/statstools"
+)
+
+func TestHistoryMetricValue(t *testing.T) {
+
+	statistics := statstools.LastNVideoStatistics{
+		VideoCount:      3,
+		VideoLengthsSum: statstools.Duration{Duration: 90 * time.Minute, Format: statstools.DurationFormatISO8601},
+		ViewCountSum:    300,
+		MostViewedVideo: statstools.MostViewedVideo{ViewCount: 150},
+		Distribution: statstools.Distribution{
+			ViewCount: statstools.DistributionSummary{P90: 140},
+		},
+	}
+
+	type testCase struct {
+		metric        string
+		expectedValue float64
+		expectError   bool
+	}
+
+	testCases := []testCase{
+		{metric: "view_count_sum", expectedValue: 300},
+		{metric: "video_count", expectedValue: 3},
+		{metric: "video_lengths_sum", expectedValue: 5400},
+		{metric: "most_viewed_video.view_count", expectedValue: 150},
+		{metric: "distribution.view_count.p90", expectedValue: 140},
+		{metric: "most_viewed_video.title", expectError: true},
+		{metric: "window.since", expectError: true},
+		{metric: "unknown", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.metric, func(t *testing.T) {
+
+			metric, err := statstools.ParseHistoryMetric(tc.metric)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := metric.Value(statistics); got != tc.expectedValue {
+				t.Errorf("expected %v, got %v", tc.expectedValue, got)
+			}
+		})
+	}
+}
+
+func TestHistoryBucketCount(t *testing.T) {
+
+	to := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	year := 365 * 24 * time.Hour
+
+	type testCase struct {
+		name          string
+		from          time.Time
+		bucket        time.Duration
+		expectedCount int
+	}
+
+	testCases := []testCase{
+		{name: "Partial last bucket is counted", from: to.Add(-36 * time.Hour), bucket: 24 * time.Hour, expectedCount: 2},
+		{name: "Span beyond the range of a Duration", from: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), bucket: year, expectedCount: 2027},
+		{name: "Small buckets over a very long span", from: time.Date(1700, time.January, 1, 0, 0, 0, 0, time.UTC), bucket: time.Minute, expectedCount: 171459360},
+		{name: "From after to", from: to.Add(time.Hour), bucket: time.Hour, expectedCount: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := statstools.HistoryBucketCount(tc.from, to, tc.bucket); got != tc.expectedCount {
+				t.Errorf("expected %d buckets, got %d", tc.expectedCount, got)
+			}
+		})
+	}
+}
+
+func TestDownsampleSnapshotsBeyondDurationRange(t *testing.T) {
+
+	from := time.Date(1700, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	collectedAt := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	snapshots := []statstools.Snapshot{{CollectedAt: collectedAt, Statistics: statstools.LastNVideoStatistics{ViewCountSum: 100}}}
+
+	buckets := statstools.DownsampleSnapshots(snapshots, "view_count_sum", from, to, 365*24*time.Hour, statstools.GapFillNull)
+
+	if len(buckets) != 327 {
+		t.Fatalf("expected 327 buckets, got %d", len(buckets))
+	}
+
+	for _, bucket := range buckets {
+		contains := !collectedAt.Before(bucket.Start) && collectedAt.Before(bucket.Start.Add(365*24*time.Hour))
+		if contains != (bucket.SnapshotCount == 1) {
+			t.Errorf("bucket starting %s has %d snapshots", bucket.Start, bucket.SnapshotCount)
+		}
+	}
+
+	if last := buckets[len(buckets)-1]; !to.Before(last.Start.Add(365 * 24 * time.Hour)) {
+		t.Errorf("expected the last bucket to cover to, it starts %s", last.Start)
+	}
+}
+
+func TestDownsampleSnapshots(t *testing.T) {
+
+	day := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	snapshot := func(offset time.Duration, viewCountSum int) statstools.Snapshot {
+		return statstools.Snapshot{
+			Login:       "good_user",
+			CollectedAt: day.Add(offset),
+			Statistics:  statstools.LastNVideoStatistics{ViewCountSum: viewCountSum},
+		}
+	}
+
+	snapshots := []statstools.Snapshot{
+		snapshot(-time.Hour, 1000),
+		snapshot(6*time.Hour, 100),
+		snapshot(12*time.Hour, 200),
+		snapshot(18*time.Hour, 600),
+		snapshot(48*time.Hour+time.Hour, 400),
+		snapshot(72*time.Hour, 1000),
+	}
+
+	type testCase struct {
+		name         string
+		from         time.Time
+		to           time.Time
+		bucket       time.Duration
+		fill         statstools.GapFill
+		expectedBody string
+	}
+
+	testCases := []testCase{
+		{
+			name:   "Daily buckets with null gaps",
+			from:   day,
+			to:     day.Add(72 * time.Hour),
+			bucket: 24 * time.Hour,
+			fill:   statstools.GapFillNull,
+			expectedBody: `[{"start":"2025-07-01T00:00:00Z","snapshot_count":3,"min":100,"max":600,"avg":300},` +
+				`{"start":"2025-07-02T00:00:00Z","snapshot_count":0,"min":null,"max":null,"avg":null},` +
+				`{"start":"2025-07-03T00:00:00Z","snapshot_count":1,"min":400,"max":400,"avg":400}]`,
+		},
+		{
+			name:   "Gaps carry the previous bucket forward",
+			from:   day,
+			to:     day.Add(72 * time.Hour),
+			bucket: 24 * time.Hour,
+			fill:   statstools.GapFillPrevious,
+			expectedBody: `[{"start":"2025-07-01T00:00:00Z","snapshot_count":3,"min":100,"max":600,"avg":300},` +
+				`{"start":"2025-07-02T00:00:00Z","snapshot_count":0,"min":100,"max":600,"avg":300},` +
+				`{"start":"2025-07-03T00:00:00Z","snapshot_count":1,"min":400,"max":400,"avg":400}]`,
+		},
+		{
+			name:   "Leading gaps stay null when carrying forward",
+			from:   day.Add(-24 * time.Hour),
+			to:     day.Add(24 * time.Hour),
+			bucket: 24 * time.Hour,
+			fill:   statstools.GapFillPrevious,
+			expectedBody: `[{"start":"2025-06-30T00:00:00Z","snapshot_count":1,"min":1000,"max":1000,"avg":1000},` +
+				`{"start":"2025-07-01T00:00:00Z","snapshot_count":3,"min":100,"max":600,"avg":300}]`,
+		},
+		{
+			name:         "Gaps filled with zero",
+			from:         day.Add(24 * time.Hour),
+			to:           day.Add(48 * time.Hour),
+			bucket:       24 * time.Hour,
+			fill:         statstools.GapFillZero,
+			expectedBody: `[{"start":"2025-07-02T00:00:00Z","snapshot_count":0,"min":0,"max":0,"avg":0}]`,
+		},
+		{
+			name:   "Unaligned from starts the first bucket early and excludes earlier snapshots",
+			from:   day.Add(9 * time.Hour),
+			to:     day.Add(24 * time.Hour),
+			bucket: 12 * time.Hour,
+			fill:   statstools.GapFillNull,
+			expectedBody: `[{"start":"2025-07-01T00:00:00Z","snapshot_count":0,"min":null,"max":null,"avg":null},` +
+				`{"start":"2025-07-01T12:00:00Z","snapshot_count":2,"min":200,"max":600,"avg":400}]`,
+		},
+		{
+			name:         "Empty range",
+			from:         day,
+			to:           day,
+			bucket:       24 * time.Hour,
+			fill:         statstools.GapFillNull,
+			expectedBody: `[]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			buckets := statstools.DownsampleSnapshots(snapshots, "view_count_sum", tc.from, tc.to, tc.bucket, tc.fill)
+
+			body, err := json.Marshal(buckets)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(body) != tc.expectedBody {
+				t.Errorf("\nwant %s\n got %s", tc.expectedBody, body)
+			}
+		})
+	}
+}
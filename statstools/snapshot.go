@@ -0,0 +1,14 @@
+package statstools
+
+import (
+	"time"
+)
+
+// Snapshot records the statistics of a streamer's most recent videos at a point in time, so their
+// growth can be followed across snapshots.
+type Snapshot struct {
+	Login       string               `json:"login"`
+	UserID      string               `json:"user_id"`
+	CollectedAt time.Time            `json:"collected_at"`
+	Statistics  LastNVideoStatistics `json:"statistics"`
+}
@@ -2,6 +2,7 @@ package statstools
 
 import (
 	"fmt"
+	"math"
 	"time"
 	"ttv-statistics/helixclient"
 )
@@ -11,21 +12,42 @@ type MostViewedVideo struct {
 	ViewCount int    `json:"view_count"`
 }
 
+const (
+	// MaxPrecision is the largest number of decimal places floating point statistics can be rounded to
+	MaxPrecision = 10
+)
+
 type LastNVideoStatistics struct {
-	VideoLengthsSum  time.Duration   `json:"video_lengths_sum"`
-	ViewCountSum     int             `json:"view_count_sum"`
-	ViewCountAvg     int             `json:"view_count_avg"`
-	ViewPerMinuteAvg int             `json:"view_per_minute_avg"`
-	MostViewedVideo  MostViewedVideo `json:"most_viewed_video"`
+	VideoCount       int      `json:"video_count"`
+	VideoLengthsSum  Duration `json:"video_lengths_sum"`
+	ViewCountSum     int      `json:"view_count_sum"`
+	ViewCountAvg     int      `json:"view_count_avg"`
+	ViewPerMinuteAvg int      `json:"view_per_minute_avg"`
+
+	// unlike the legacy integer averages above these are not truncated
+	ViewCountAvgFloat     float64 `json:"view_count_avg_float"`
+	ViewPerMinuteAvgFloat float64 `json:"view_per_minute_avg_float"`
+
+	MostViewedVideo MostViewedVideo `json:"most_viewed_video"`
+	Distribution    Distribution    `json:"distribution"`
+
+	Filters helixclient.VideoFilter `json:"filters"`
+	Window  helixclient.VideoWindow `json:"window,omitzero"`
 }
 
+// AggregateStreamerVideoStatistics summarises videosData. A channel without videos is not an error, it is
+// described by zero valued statistics with a VideoCount of 0.
 func AggregateStreamerVideoStatistics(videosData []helixclient.VideoInfo) (aggregateData LastNVideoStatistics, err error) {
 
+	aggregateData.VideoCount = len(videosData)
+
 	if len(videosData) == 0 {
-		return LastNVideoStatistics{}, fmt.Errorf("message=%q", "no video data provided")
+		return aggregateData, nil
 	}
 
 	topVideoViewCount := 0
+	viewCounts := make([]float64, 0, len(videosData))
+	durations := make([]float64, 0, len(videosData))
 
 	for _, videoData := range videosData {
 
@@ -44,14 +66,45 @@ func AggregateStreamerVideoStatistics(videosData []helixclient.VideoInfo) (aggre
 			return LastNVideoStatistics{}, fmt.Errorf("message=%q innermessage=%v", "failed to parse duration", err)
 		}
 
-		aggregateData.VideoLengthsSum += duration
+		aggregateData.VideoLengthsSum.Duration += duration
+
+		viewCounts = append(viewCounts, float64(videoData.ViewCount))
+		durations = append(durations, duration.Seconds())
+	}
 
+	aggregateData.Distribution = Distribution{
+		ViewCount:       Summarise(viewCounts),
+		DurationSeconds: Summarise(durations),
 	}
 
 	aggregateData.ViewCountAvg = aggregateData.ViewCountSum / len(videosData)
-	if aggregateData.VideoLengthsSum > 0 {
-		aggregateData.ViewPerMinuteAvg = aggregateData.ViewCountSum / int(aggregateData.VideoLengthsSum.Minutes())
+	aggregateData.ViewCountAvgFloat = float64(aggregateData.ViewCountSum) / float64(len(videosData))
+
+	// the integer average keeps dividing by whole minutes for existing consumers, and only falls back to
+	// fractional minutes when the videos total less than one, where whole minutes would be a zero divisor
+	if aggregateData.VideoLengthsSum.Duration > 0 {
+		aggregateData.ViewPerMinuteAvgFloat = float64(aggregateData.ViewCountSum) / aggregateData.VideoLengthsSum.Minutes()
+		aggregateData.ViewPerMinuteAvg = int(aggregateData.ViewPerMinuteAvgFloat)
+		if minutes := int(aggregateData.VideoLengthsSum.Minutes()); minutes >= 1 {
+			aggregateData.ViewPerMinuteAvg = aggregateData.ViewCountSum / minutes
+		}
 	}
 
 	return aggregateData, err
 }
+
+// WithPrecision returns the statistics with every floating point value rounded to precision decimal places.
+func (s LastNVideoStatistics) WithPrecision(precision int) LastNVideoStatistics {
+
+	s.ViewCountAvgFloat = round(s.ViewCountAvgFloat, precision)
+	s.ViewPerMinuteAvgFloat = round(s.ViewPerMinuteAvgFloat, precision)
+	s.Distribution.ViewCount = s.Distribution.ViewCount.withPrecision(precision)
+	s.Distribution.DurationSeconds = s.Distribution.DurationSeconds.withPrecision(precision)
+
+	return s
+}
+
+func round(value float64, precision int) float64 {
+	scale := math.Pow10(precision)
+	return math.Round(value*scale) / scale
+}
@@ -1,6 +1,7 @@
 package statstools_test
 
 import (
+	"math"
 	"testing"
 	"time"
 	"ttv-statistics/helixclient"
@@ -16,12 +17,16 @@ func TestAggregateStreamerVideoStatistics(t *testing.T) {
 		expectedError string
 	}
 
+	viewCountDistribution := statstools.DistributionSummary{
+		Min: 1000, Max: 2000, Median: 1500, P25: 1250, P75: 1750, P90: 1900,
+		StdDev: 408.248290463863, CoefficientOfVariation: 0.272165526975909,
+	}
+
 	testCases := []testCase{
 		{
-			name:          "No video data",
-			inputs:        []helixclient.VideoInfo{},
-			expected:      statstools.LastNVideoStatistics{},
-			expectedError: `message="no video data provided"`,
+			name:     "No video data",
+			inputs:   []helixclient.VideoInfo{},
+			expected: statstools.LastNVideoStatistics{},
 		},
 		{
 			name: "Valid video data",
@@ -43,11 +48,22 @@ func TestAggregateStreamerVideoStatistics(t *testing.T) {
 				},
 			},
 			expected: statstools.LastNVideoStatistics{
-				VideoLengthsSum:  4*time.Hour + 30*time.Minute,
+				VideoCount:       3,
+				VideoLengthsSum:  statstools.Duration{Duration: 4*time.Hour + 30*time.Minute},
 				ViewCountSum:     4500,
 				MostViewedVideo:  statstools.MostViewedVideo{Title: "Second Video", ViewCount: 2000},
 				ViewCountAvg:     1500,
 				ViewPerMinuteAvg: 16,
+
+				ViewCountAvgFloat:     1500,
+				ViewPerMinuteAvgFloat: 4500.0 / 270.0,
+				Distribution: statstools.Distribution{
+					ViewCount: viewCountDistribution,
+					DurationSeconds: statstools.DistributionSummary{
+						Min: 2700, Max: 8100, Median: 5400, P25: 4050, P75: 6750, P90: 7560,
+						StdDev: 2204.540768504859, CoefficientOfVariation: 0.408248290463863,
+					},
+				},
 			},
 		},
 		{
@@ -71,11 +87,115 @@ func TestAggregateStreamerVideoStatistics(t *testing.T) {
 				},
 			},
 			expected: statstools.LastNVideoStatistics{
-				VideoLengthsSum:  0 * time.Minute,
+				VideoCount:       3,
+				VideoLengthsSum:  statstools.Duration{Duration: 0 * time.Minute},
 				ViewCountSum:     4500,
 				MostViewedVideo:  statstools.MostViewedVideo{Title: "Second Video", ViewCount: 2000},
 				ViewCountAvg:     1500,
 				ViewPerMinuteAvg: 0,
+
+				ViewCountAvgFloat:     1500,
+				ViewPerMinuteAvgFloat: 0,
+				Distribution: statstools.Distribution{
+					ViewCount: viewCountDistribution,
+				},
+			},
+		},
+		{
+			name:          "Small streamer with fewer views than minutes streamed",
+			expectedError: "",
+			inputs: []helixclient.VideoInfo{
+				{
+					Duration:  "10h",
+					ViewCount: 600,
+					Title:     "First Video",
+				},
+				{
+					Duration:  "8h20m",
+					ViewCount: 400,
+					Title:     "Second Video",
+				},
+			},
+			expected: statstools.LastNVideoStatistics{
+				VideoCount:       2,
+				VideoLengthsSum:  statstools.Duration{Duration: 18*time.Hour + 20*time.Minute},
+				ViewCountSum:     1000,
+				MostViewedVideo:  statstools.MostViewedVideo{Title: "First Video", ViewCount: 600},
+				ViewCountAvg:     500,
+				ViewPerMinuteAvg: 0,
+
+				ViewCountAvgFloat:     500,
+				ViewPerMinuteAvgFloat: 1000.0 / 1100.0,
+				Distribution: statstools.Distribution{
+					ViewCount: statstools.DistributionSummary{
+						Min: 400, Max: 600, Median: 500, P25: 450, P75: 550, P90: 580,
+						StdDev: 100, CoefficientOfVariation: 0.2,
+					},
+					DurationSeconds: statstools.DistributionSummary{
+						Min: 30000, Max: 36000, Median: 33000, P25: 31500, P75: 34500, P90: 35400,
+						StdDev: 3000, CoefficientOfVariation: 3000.0 / 33000.0,
+					},
+				},
+			},
+		},
+		{
+			name:          "Integer views per minute divide by whole minutes",
+			expectedError: "",
+			inputs: []helixclient.VideoInfo{
+				{
+					Duration:  "50m59s",
+					ViewCount: 100,
+					Title:     "First Video",
+				},
+			},
+			expected: statstools.LastNVideoStatistics{
+				VideoCount:       1,
+				VideoLengthsSum:  statstools.Duration{Duration: 50*time.Minute + 59*time.Second},
+				ViewCountSum:     100,
+				MostViewedVideo:  statstools.MostViewedVideo{Title: "First Video", ViewCount: 100},
+				ViewCountAvg:     100,
+				ViewPerMinuteAvg: 2,
+
+				ViewCountAvgFloat:     100,
+				ViewPerMinuteAvgFloat: 100 / (50 + 59.0/60),
+				Distribution: statstools.Distribution{
+					ViewCount: statstools.DistributionSummary{
+						Min: 100, Max: 100, Median: 100, P25: 100, P75: 100, P90: 100,
+					},
+					DurationSeconds: statstools.DistributionSummary{
+						Min: 3059, Max: 3059, Median: 3059, P25: 3059, P75: 3059, P90: 3059,
+					},
+				},
+			},
+		},
+		{
+			name:          "Videos totalling less than a minute",
+			expectedError: "",
+			inputs: []helixclient.VideoInfo{
+				{
+					Duration:  "30s",
+					ViewCount: 10,
+					Title:     "First Video",
+				},
+			},
+			expected: statstools.LastNVideoStatistics{
+				VideoCount:       1,
+				VideoLengthsSum:  statstools.Duration{Duration: 30 * time.Second},
+				ViewCountSum:     10,
+				MostViewedVideo:  statstools.MostViewedVideo{Title: "First Video", ViewCount: 10},
+				ViewCountAvg:     10,
+				ViewPerMinuteAvg: 20,
+
+				ViewCountAvgFloat:     10,
+				ViewPerMinuteAvgFloat: 20,
+				Distribution: statstools.Distribution{
+					ViewCount: statstools.DistributionSummary{
+						Min: 10, Max: 10, Median: 10, P25: 10, P75: 10, P90: 10,
+					},
+					DurationSeconds: statstools.DistributionSummary{
+						Min: 30, Max: 30, Median: 30, P25: 30, P75: 30, P90: 30,
+					},
+				},
 			},
 		},
 		{
@@ -101,9 +221,69 @@ func TestAggregateStreamerVideoStatistics(t *testing.T) {
 				t.Errorf("unexpected error: want: %v, got: %v", tc.expectedError, err)
 			}
 
+			if !distributionSummaryApproxEqual(result.Distribution.ViewCount, tc.expected.Distribution.ViewCount) ||
+				!distributionSummaryApproxEqual(result.Distribution.DurationSeconds, tc.expected.Distribution.DurationSeconds) {
+				t.Errorf("unexpected distribution: \nwant: %+v, \n got: %+v", tc.expected.Distribution, result.Distribution)
+			}
+
+			// the distribution has been compared within a tolerance above
+			result.Distribution = tc.expected.Distribution
 			if result != tc.expected {
 				t.Errorf("unexpected result: \nwant: %+v, \n got: %+v", tc.expected, result)
 			}
 		})
 	}
 }
+
+func distributionSummaryApproxEqual(a, b statstools.DistributionSummary) bool {
+	const tolerance = 1e-9
+
+	pairs := [][2]float64{
+		{a.Min, b.Min},
+		{a.Max, b.Max},
+		{a.Median, b.Median},
+		{a.P25, b.P25},
+		{a.P75, b.P75},
+		{a.P90, b.P90},
+		{a.StdDev, b.StdDev},
+		{a.CoefficientOfVariation, b.CoefficientOfVariation},
+	}
+
+	for _, pair := range pairs {
+		if math.Abs(pair[0]-pair[1]) > tolerance*math.Max(1, math.Abs(pair[1])) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestLastNVideoStatisticsWithPrecision(t *testing.T) {
+
+	statistics := statstools.LastNVideoStatistics{
+		ViewCountSum:          1000,
+		ViewCountAvgFloat:     333.3333333333333,
+		ViewPerMinuteAvgFloat: 0.9090909090909091,
+		Distribution: statstools.Distribution{
+			ViewCount: statstools.DistributionSummary{StdDev: 40.824829046386306},
+		},
+	}
+
+	result := statistics.WithPrecision(2)
+
+	if result.ViewCountAvgFloat != 333.33 {
+		t.Errorf("unexpected view count average: want: %v, got: %v", 333.33, result.ViewCountAvgFloat)
+	}
+
+	if result.ViewPerMinuteAvgFloat != 0.91 {
+		t.Errorf("unexpected views per minute average: want: %v, got: %v", 0.91, result.ViewPerMinuteAvgFloat)
+	}
+
+	if result.Distribution.ViewCount.StdDev != 40.82 {
+		t.Errorf("unexpected view count standard deviation: want: %v, got: %v", 40.82, result.Distribution.ViewCount.StdDev)
+	}
+
+	if result.ViewCountSum != statistics.ViewCountSum {
+		t.Errorf("unexpected view count sum: want: %v, got: %v", statistics.ViewCountSum, result.ViewCountSum)
+	}
+}
@@ -0,0 +1,11 @@
+package storage
+
+// FailWrite leaves partial at the end of the store's file, as a write failing part way through would,
+// and rolls it back as write does.
+func FailWrite(s *FileStore, partial string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.file.WriteString(partial)
+	s.rollback()
+}
@@ -0,0 +1,324 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/statstools"
+)
+
+const (
+	recordStreamer string = "streamer"
+	recordVideos   string = "videos"
+	recordSnapshot string = "snapshot"
+)
+
+// record is one line of a FileStore's file
+type record struct {
+	Kind     string                  `json:"kind"`
+	Streamer *helixclient.UserInfo   `json:"streamer,omitempty"`
+	UserID   string                  `json:"user_id,omitempty"`
+	Videos   []helixclient.VideoInfo `json:"videos,omitempty"`
+	Snapshot *statstools.Snapshot    `json:"snapshot,omitempty"`
+}
+
+// FileStore is a Repository kept in a single file of JSON lines, one per change. The file is read
+// into memory when the store is opened, which serves every read, and each change is appended and
+// synced to the file before it is applied.
+type FileStore struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	size   int64
+	failed error
+	memory *MemoryStore
+}
+
+var _ Repository = (*FileStore)(nil)
+
+// OpenFileStore opens the store at path, creating it if it does not exist. The file is compacted as it
+// is opened, so replaced streamers and videos do not accumulate, and a last line left incomplete by a
+// crash is discarded.
+func OpenFileStore(path string) (*FileStore, error) {
+
+	memory := NewMemoryStore()
+
+	if err := replay(path, memory); err != nil {
+		return nil, fmt.Errorf("message=%s path=%s error=%w", "failed to read store", path, err)
+	}
+
+	if err := compact(path, memory); err != nil {
+		return nil, fmt.Errorf("message=%s path=%s error=%w", "failed to compact store", path, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("message=%s path=%s error=%w", "failed to open store", path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("message=%s path=%s error=%w", "failed to open store", path, err)
+	}
+
+	return &FileStore{path: path, file: file, size: info.Size(), memory: memory}, nil
+}
+
+func replay(path string, memory *MemoryStore) error {
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	for line := 1; ; line++ {
+
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// a line without a newline was not completely written
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var r record
+		if err := json.Unmarshal(data, &r); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if err := memory.apply(r); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// compact rewrites the store at path with one record per streamer, per streamer's videos and per
+// snapshot, replacing the file only once the new one is completely written. The new file keeps the
+// mode of the old one, and the directory is synced so the replacement survives a crash.
+func compact(path string, memory *MemoryStore) error {
+
+	mode := os.FileMode(0o644)
+	info, err := os.Stat(path)
+	if err == nil {
+		mode = info.Mode().Perm()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if err := temp.Chmod(mode); err != nil {
+		temp.Close()
+		return err
+	}
+
+	writer := bufio.NewWriter(temp)
+	encoder := json.NewEncoder(writer)
+
+	for _, r := range memory.records() {
+		if err := encoder.Encode(r); err != nil {
+			temp.Close()
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		temp.Close()
+		return err
+	}
+
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+
+	if err := temp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir makes changes to the entries of the directory at path, such as a rename, durable
+func syncDir(path string) error {
+
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	if err := dir.Sync(); err != nil {
+		dir.Close()
+		return err
+	}
+
+	return dir.Close()
+}
+
+// apply makes the change described by r
+func (s *MemoryStore) apply(r record) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Kind == recordStreamer && r.Streamer != nil:
+		s.saveStreamer(*r.Streamer)
+	case r.Kind == recordVideos:
+		s.saveVideos(r.UserID, r.Videos)
+	case r.Kind == recordSnapshot && r.Snapshot != nil:
+		s.saveSnapshot(*r.Snapshot)
+	default:
+		return fmt.Errorf("invalid %q record", r.Kind)
+	}
+
+	return nil
+}
+
+// records describes the contents of the store as the fewest records that recreate it
+func (s *MemoryStore) records() []record {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := []record{}
+
+	for _, user := range s.streamers {
+		records = append(records, record{Kind: recordStreamer, Streamer: &user})
+	}
+
+	for userID, videos := range s.videos {
+		r := record{Kind: recordVideos, UserID: userID, Videos: make([]helixclient.VideoInfo, 0, len(videos))}
+		for _, video := range videos {
+			r.Videos = append(r.Videos, video)
+		}
+		records = append(records, r)
+	}
+
+	for _, snapshots := range s.snapshots {
+		for _, snapshot := range snapshots {
+			records = append(records, record{Kind: recordSnapshot, Snapshot: &snapshot})
+		}
+	}
+
+	return records
+}
+
+// write appends r to the file and applies it once it is synced, so a change reported as saved
+// survives a crash. A failed write is truncated away, so the next record starts on a line of its own,
+// and if that fails too the store refuses further writes rather than corrupt the file.
+func (s *FileStore) write(r record) error {
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("message=%s kind=%s error=%w", "failed to encode record", r.Kind, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return ErrClosed
+	}
+
+	if s.failed != nil {
+		return fmt.Errorf("message=%s path=%s kind=%s error=%w", "store failed after an incomplete write", s.path, r.Kind, s.failed)
+	}
+
+	line := append(data, '\n')
+
+	if _, err := s.file.Write(line); err != nil {
+		s.rollback()
+		return fmt.Errorf("message=%s path=%s kind=%s error=%w", "failed to write record", s.path, r.Kind, err)
+	}
+
+	if err := s.file.Sync(); err != nil {
+		s.rollback()
+		return fmt.Errorf("message=%s path=%s kind=%s error=%w", "failed to sync record", s.path, r.Kind, err)
+	}
+
+	s.size += int64(len(line))
+
+	return s.memory.apply(r)
+}
+
+// rollback truncates the file back to the end of the last complete record, marking the store failed if
+// it cannot. It expects s.mu to be held.
+func (s *FileStore) rollback() {
+
+	if err := s.file.Truncate(s.size); err != nil {
+		s.failed = err
+	}
+}
+
+func (s *FileStore) SaveStreamer(ctx context.Context, user helixclient.UserInfo) error {
+	return s.write(record{Kind: recordStreamer, Streamer: &user})
+}
+
+func (s *FileStore) Streamer(ctx context.Context, login string) (helixclient.UserInfo, error) {
+	return s.memory.Streamer(ctx, login)
+}
+
+func (s *FileStore) Streamers(ctx context.Context) ([]helixclient.UserInfo, error) {
+	return s.memory.Streamers(ctx)
+}
+
+func (s *FileStore) SaveVideos(ctx context.Context, userID string, videos []helixclient.VideoInfo) error {
+	return s.write(record{Kind: recordVideos, UserID: userID, Videos: videos})
+}
+
+func (s *FileStore) Videos(ctx context.Context, userID string) ([]helixclient.VideoInfo, error) {
+	return s.memory.Videos(ctx, userID)
+}
+
+func (s *FileStore) SaveSnapshot(ctx context.Context, snapshot statstools.Snapshot) error {
+	return s.write(record{Kind: recordSnapshot, Snapshot: &snapshot})
+}
+
+func (s *FileStore) Snapshots(ctx context.Context, login string, from, to time.Time) ([]statstools.Snapshot, error) {
+	return s.memory.Snapshots(ctx, login, from, to)
+}
+
+func (s *FileStore) Close() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+	s.memory.Close()
+
+	if err != nil {
+		return fmt.Errorf("message=%s path=%s error=%w", "failed to close store", s.path, err)
+	}
+
+	return nil
+}
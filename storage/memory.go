@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/statstools"
+)
+
+// MemoryStore is a Repository held in memory. Its contents are lost when the process exits.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	closed    bool
+	streamers map[string]helixclient.UserInfo
+	videos    map[string]map[string]helixclient.VideoInfo
+	snapshots map[string][]statstools.Snapshot
+}
+
+var _ Repository = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		streamers: map[string]helixclient.UserInfo{},
+		videos:    map[string]map[string]helixclient.VideoInfo{},
+		snapshots: map[string][]statstools.Snapshot{},
+	}
+}
+
+func (s *MemoryStore) SaveStreamer(ctx context.Context, user helixclient.UserInfo) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	s.saveStreamer(user)
+
+	return nil
+}
+
+func (s *MemoryStore) saveStreamer(user helixclient.UserInfo) {
+	s.streamers[user.ID] = user
+}
+
+func (s *MemoryStore) Streamer(ctx context.Context, login string) (helixclient.UserInfo, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return helixclient.UserInfo{}, ErrClosed
+	}
+
+	for _, user := range s.streamers {
+		if strings.EqualFold(user.Login, login) {
+			return user, nil
+		}
+	}
+
+	return helixclient.UserInfo{}, ErrNotFound
+}
+
+func (s *MemoryStore) Streamers(ctx context.Context) ([]helixclient.UserInfo, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	streamers := make([]helixclient.UserInfo, 0, len(s.streamers))
+	for _, user := range s.streamers {
+		streamers = append(streamers, user)
+	}
+
+	slices.SortFunc(streamers, func(a, b helixclient.UserInfo) int {
+		return cmp.Or(cmp.Compare(a.Login, b.Login), cmp.Compare(a.ID, b.ID))
+	})
+
+	return streamers, nil
+}
+
+func (s *MemoryStore) SaveVideos(ctx context.Context, userID string, videos []helixclient.VideoInfo) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	s.saveVideos(userID, videos)
+
+	return nil
+}
+
+func (s *MemoryStore) saveVideos(userID string, videos []helixclient.VideoInfo) {
+
+	userVideos, ok := s.videos[userID]
+	if !ok {
+		userVideos = map[string]helixclient.VideoInfo{}
+		s.videos[userID] = userVideos
+	}
+
+	for _, video := range videos {
+		userVideos[video.ID] = video
+	}
+}
+
+func (s *MemoryStore) Videos(ctx context.Context, userID string) ([]helixclient.VideoInfo, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	videos := make([]helixclient.VideoInfo, 0, len(s.videos[userID]))
+	for _, video := range s.videos[userID] {
+		videos = append(videos, video)
+	}
+
+	slices.SortFunc(videos, func(a, b helixclient.VideoInfo) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	return videos, nil
+}
+
+func (s *MemoryStore) SaveSnapshot(ctx context.Context, snapshot statstools.Snapshot) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	s.saveSnapshot(snapshot)
+
+	return nil
+}
+
+// saveSnapshot keeps the snapshots of each login ordered by collection time, so ranges can be found
+// by binary search
+func (s *MemoryStore) saveSnapshot(snapshot statstools.Snapshot) {
+
+	login := strings.ToLower(snapshot.Login)
+	snapshots := s.snapshots[login]
+
+	i, _ := slices.BinarySearchFunc(snapshots, snapshot.CollectedAt, func(s statstools.Snapshot, t time.Time) int {
+		// snapshots collected at the same time are kept in the order they were saved
+		return cmp.Or(s.CollectedAt.Compare(t), -1)
+	})
+
+	s.snapshots[login] = slices.Insert(snapshots, i, snapshot)
+}
+
+func (s *MemoryStore) Snapshots(ctx context.Context, login string, from, to time.Time) ([]statstools.Snapshot, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	snapshots := s.snapshots[strings.ToLower(login)]
+
+	start := 0
+	if !from.IsZero() {
+		start, _ = slices.BinarySearchFunc(snapshots, from, func(s statstools.Snapshot, t time.Time) int {
+			return s.CollectedAt.Compare(t)
+		})
+	}
+
+	end := len(snapshots)
+	if !to.IsZero() {
+		end, _ = slices.BinarySearchFunc(snapshots, to, func(s statstools.Snapshot, t time.Time) int {
+			return s.CollectedAt.Compare(t)
+		})
+	}
+
+	if start >= end {
+		return []statstools.Snapshot{}, nil
+	}
+
+	return slices.Clone(snapshots[start:end]), nil
+}
+
+func (s *MemoryStore) Close() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/statstools"
+)
+
+const (
+	StoreMemory string = "memory"
+	StoreFile   string = "file"
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnknownStore = errors.New("unknown store")
+	ErrClosed       = errors.New("store is closed")
+)
+
+// Repository records streamers, their videos and snapshots of their statistics. Implementations are
+// safe for concurrent use.
+type Repository interface {
+	// SaveStreamer records user, replacing any streamer with the same ID.
+	SaveStreamer(ctx context.Context, user helixclient.UserInfo) error
+
+	// Streamer returns the streamer with login, or ErrNotFound.
+	Streamer(ctx context.Context, login string) (helixclient.UserInfo, error)
+
+	// Streamers returns every streamer, ordered by login.
+	Streamers(ctx context.Context) ([]helixclient.UserInfo, error)
+
+	// SaveVideos records videos of the streamer with userID, replacing any videos with the same ID.
+	SaveVideos(ctx context.Context, userID string, videos []helixclient.VideoInfo) error
+
+	// Videos returns the videos recorded for the streamer with userID, newest first.
+	Videos(ctx context.Context, userID string) ([]helixclient.VideoInfo, error)
+
+	SaveSnapshot(ctx context.Context, snapshot statstools.Snapshot) error
+
+	// Snapshots returns the snapshots of login collected in [from, to), oldest first. A zero from or
+	// to leaves that end of the range open.
+	Snapshots(ctx context.Context, login string, from, to time.Time) ([]statstools.Snapshot, error)
+
+	Close() error
+}
+
+// Open returns the repository named by store, one of StoreMemory or StoreFile. path is the file
+// holding a StoreFile repository, and is ignored by StoreMemory.
+func Open(store, path string) (Repository, error) {
+
+	switch store {
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	case StoreFile:
+		return OpenFileStore(path)
+	default:
+		return nil, fmt.Errorf("message=%s store=%s error=%w", "failed to open store", store, ErrUnknownStore)
+	}
+}
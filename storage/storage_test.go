@@ -0,0 +1,383 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"ttv-statistics/helixclient"
+	"ttv-statistics/statstools"
+	"ttv-statistics/storage"
+)
+
+var (
+	streamerA = helixclient.UserInfo{ID: "1", Login: "streamer_a", DisplayName: "Streamer A"}
+	streamerB = helixclient.UserInfo{ID: "2", Login: "streamer_b", DisplayName: "Streamer B"}
+
+	firstCollectedAt = time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func snapshotAt(login string, hours int, viewCountSum int) statstools.Snapshot {
+	return statstools.Snapshot{
+		Login:       login,
+		UserID:      "1",
+		CollectedAt: firstCollectedAt.Add(time.Duration(hours) * time.Hour),
+		Statistics:  statstools.LastNVideoStatistics{VideoCount: 1, ViewCountSum: viewCountSum},
+	}
+}
+
+func video(id string, day, viewCount int) helixclient.VideoInfo {
+	return helixclient.VideoInfo{
+		ID:        id,
+		CreatedAt: time.Date(2025, time.June, day, 12, 0, 0, 0, time.UTC),
+		ViewCount: viewCount,
+	}
+}
+
+// openStores returns a repository of each implementation
+func openStores(t *testing.T) map[string]storage.Repository {
+
+	fileStore, err := storage.Open(storage.StoreFile, filepath.Join(t.TempDir(), "store.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	memoryStore, err := storage.Open(storage.StoreMemory, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return map[string]storage.Repository{storage.StoreFile: fileStore, storage.StoreMemory: memoryStore}
+}
+
+func TestRepositoryStreamers(t *testing.T) {
+	t.Parallel()
+
+	for name, store := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			defer store.Close()
+
+			ctx := context.Background()
+
+			if _, err := store.Streamer(ctx, streamerA.Login); !errors.Is(err, storage.ErrNotFound) {
+				t.Errorf("expected %v, got %v", storage.ErrNotFound, err)
+			}
+
+			renamed := streamerA
+			renamed.DisplayName = "Renamed"
+
+			for _, user := range []helixclient.UserInfo{streamerB, streamerA, renamed} {
+				if err := store.SaveStreamer(ctx, user); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			user, err := store.Streamer(ctx, "Streamer_A")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if user != renamed {
+				t.Errorf("expected %+v, got %+v", renamed, user)
+			}
+
+			streamers, err := store.Streamers(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(streamers) != 2 || streamers[0] != renamed || streamers[1] != streamerB {
+				t.Errorf("expected streamers ordered by login, got %+v", streamers)
+			}
+		})
+	}
+}
+
+func TestRepositoryVideos(t *testing.T) {
+	t.Parallel()
+
+	for name, store := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			defer store.Close()
+
+			ctx := context.Background()
+
+			if err := store.SaveVideos(ctx, streamerA.ID, []helixclient.VideoInfo{video("v1", 1, 10), video("v2", 2, 20)}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := store.SaveVideos(ctx, streamerA.ID, []helixclient.VideoInfo{video("v2", 2, 25), video("v3", 3, 30)}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			videos, err := store.Videos(ctx, streamerA.ID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := []string{"v3:30", "v2:25", "v1:10"}
+			if len(videos) != len(expected) {
+				t.Fatalf("expected %d videos, got %d", len(expected), len(videos))
+			}
+			for i, video := range videos {
+				if got := fmt.Sprintf("%s:%d", video.ID, video.ViewCount); got != expected[i] {
+					t.Errorf("expected video %d to be %s, got %s", i, expected[i], got)
+				}
+			}
+
+			other, err := store.Videos(ctx, streamerB.ID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(other) != 0 {
+				t.Errorf("expected no videos, got %d", len(other))
+			}
+		})
+	}
+}
+
+func TestRepositorySnapshots(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name         string
+		login        string
+		from         time.Time
+		to           time.Time
+		expectedSums []int
+	}
+
+	testCases := []testCase{
+		{
+			name:         "Open range returns every snapshot oldest first",
+			login:        streamerA.Login,
+			expectedSums: []int{0, 10, 20, 30},
+		},
+		{
+			name:         "From is inclusive and to is exclusive",
+			login:        streamerA.Login,
+			from:         firstCollectedAt.Add(time.Hour),
+			to:           firstCollectedAt.Add(3 * time.Hour),
+			expectedSums: []int{10, 20},
+		},
+		{
+			name:         "Login is case insensitive",
+			login:        "STREAMER_A",
+			from:         firstCollectedAt.Add(3 * time.Hour),
+			expectedSums: []int{30},
+		},
+		{
+			name:         "Empty range returns no snapshots",
+			login:        streamerA.Login,
+			from:         firstCollectedAt.Add(10 * time.Hour),
+			expectedSums: []int{},
+		},
+		{
+			name:         "Unknown login returns no snapshots",
+			login:        "missing_user",
+			expectedSums: []int{},
+		},
+	}
+
+	for name, store := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			defer store.Close()
+
+			ctx := context.Background()
+
+			// saved out of order, as concurrent collectors may
+			for _, snapshot := range []statstools.Snapshot{
+				snapshotAt(streamerA.Login, 2, 20),
+				snapshotAt(streamerA.Login, 0, 0),
+				snapshotAt(streamerB.Login, 1, 99),
+				snapshotAt(streamerA.Login, 3, 30),
+				snapshotAt(streamerA.Login, 1, 10),
+			} {
+				if err := store.SaveSnapshot(ctx, snapshot); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			for _, tc := range testCases {
+				snapshots, err := store.Snapshots(ctx, tc.login, tc.from, tc.to)
+				if err != nil {
+					t.Fatalf("%s: unexpected error: %v", tc.name, err)
+				}
+
+				sums := []int{}
+				for _, snapshot := range snapshots {
+					sums = append(sums, snapshot.Statistics.ViewCountSum)
+				}
+				if !slices.Equal(sums, tc.expectedSums) {
+					t.Errorf("%s: expected view count sums %v, got %v", tc.name, tc.expectedSums, sums)
+				}
+			}
+		})
+	}
+}
+
+func TestRepositoryClosed(t *testing.T) {
+	t.Parallel()
+
+	for name, store := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := store.Close(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := store.SaveSnapshot(context.Background(), snapshotAt(streamerA.Login, 0, 0)); !errors.Is(err, storage.ErrClosed) {
+				t.Errorf("expected %v, got %v", storage.ErrClosed, err)
+			}
+		})
+	}
+}
+
+func TestFileStoreReopen(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.db")
+
+	store, err := storage.OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for range 3 {
+		if err := store.SaveVideos(ctx, streamerA.ID, []helixclient.VideoInfo{video("v1", 1, 10)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := store.SaveStreamer(ctx, streamerA); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.SaveSnapshot(ctx, snapshotAt(streamerA.Login, 0, 10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a crash part way through appending a record leaves an incomplete last line
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := file.WriteString(`{"kind":"snapshot","snap`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file.Close()
+
+	store, err = storage.OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer store.Close()
+
+	if user, err := store.Streamer(ctx, streamerA.Login); err != nil || user != streamerA {
+		t.Errorf("expected %+v, got %+v, error %v", streamerA, user, err)
+	}
+	if videos, err := store.Videos(ctx, streamerA.ID); err != nil || len(videos) != 1 {
+		t.Errorf("expected 1 video, got %d, error %v", len(videos), err)
+	}
+	if snapshots, err := store.Snapshots(ctx, streamerA.Login, time.Time{}, time.Time{}); err != nil || len(snapshots) != 1 {
+		t.Errorf("expected 1 snapshot, got %d, error %v", len(snapshots), err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 3 || !strings.HasSuffix(string(data), "\n") {
+		t.Errorf("expected the store to be compacted to 3 complete records, got %d lines", lines)
+	}
+}
+
+func TestFileStoreFailedWrite(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.db")
+
+	store, err := storage.OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := store.SaveStreamer(ctx, streamerA); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	storage.FailWrite(store, `{"kind":"streamer","stre`)
+
+	if err := store.SaveStreamer(ctx, streamerB); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store, err = storage.OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("expected the store to reopen after a failed write, got %v", err)
+	}
+	defer store.Close()
+
+	if streamers, err := store.Streamers(ctx); err != nil || len(streamers) != 2 {
+		t.Errorf("expected 2 streamers, got %d, error %v", len(streamers), err)
+	}
+}
+
+func TestFileStoreKeepsMode(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "store.db")
+	if err := os.WriteFile(path, nil, 0o640); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store, err := storage.OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer store.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o640 {
+		t.Errorf("expected compaction to keep mode %o, got %o", 0o640, mode)
+	}
+}
+
+func TestFileStoreCorrupt(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "store.db")
+	if err := os.WriteFile(path, []byte("not json\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := storage.OpenFileStore(path); err == nil {
+		t.Errorf("expected an error, got none")
+	}
+}
+
+func TestOpenUnknownStore(t *testing.T) {
+	t.Parallel()
+
+	if _, err := storage.Open("postgres", ""); !errors.Is(err, storage.ErrUnknownStore) {
+		t.Errorf("expected %v, got %v", storage.ErrUnknownStore, err)
+	}
+}
@@ -2,85 +2,234 @@ package testutil
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 	"ttv-statistics/helixclient"
 )
 
+const (
+	PaginatedUserID     = "paginated_user"
+	PaginatedVideoCount = 250
+	PaginatedClipCount  = 250
+
+	StubClientID       = "stub-client-id"
+	StubClientSecret   = "stub-client-secret"
+	StubAccessToken    = "stub-access-token"
+	AuthorisedUserName = "authorised_user"
+	SecondUserName     = "second_user"
+	NoVideosUserName   = "no_videos_user"
+
+	// any login or id with these prefixes is known to the stub helix /users endpoint
+	BulkUserPrefix   = "bulk_user_"
+	BulkUserIDPrefix = "bulk-"
+)
+
+var (
+	// stubUsers maps the logins known to the stub helix /users endpoint to their user data
+	stubUsers = map[string]helixclient.UserInfo{
+		"good_user":                   stubUser("good_user", "good_user", "Streamer A"),
+		"extra_data_user":             stubUser("good_user", "extra_data_user", "Streamer A"),
+		"good_user_bad_video_request": stubUser("00000", "good_user_bad_video_request", "Streamer A"),
+		AuthorisedUserName:            stubUser("good_user", AuthorisedUserName, "Streamer A"),
+		SecondUserName:                stubUser(SecondUserName, SecondUserName, "Streamer B"),
+		NoVideosUserName:              stubUser(NoVideosUserName, NoVideosUserName, "Streamer C"),
+	}
+
+	// stubUserIDs maps the user IDs known to the stub helix /users endpoint to their login
+	stubUserIDs = map[string]string{
+		"good_user":      "good_user",
+		"00000":          "good_user_bad_video_request",
+		SecondUserName:   SecondUserName,
+		NoVideosUserName: NoVideosUserName,
+	}
+
+	// stubVideos maps the user IDs known to the stub helix /videos endpoint to their videos
+	stubVideos = map[string][]helixclient.VideoInfo{
+		"good_user": {
+			{
+				ID:        "v1",
+				Title:     "Sample Video 1",
+				CreatedAt: time.Date(2025, time.June, 3, 12, 0, 0, 0, time.UTC),
+				Duration:  "30m",
+				ViewCount: 150,
+				Type:      helixclient.VideoTypeArchive,
+			},
+			{
+				ID:        "v2",
+				Title:     "Sample Video 2",
+				CreatedAt: time.Date(2025, time.June, 2, 12, 0, 0, 0, time.UTC),
+				Duration:  "20m",
+				ViewCount: 100,
+				Type:      helixclient.VideoTypeHighlight,
+			},
+			{
+				ID:        "v3",
+				Title:     "Sample Video 3",
+				CreatedAt: time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC),
+				Duration:  "10m",
+				ViewCount: 50,
+				Type:      helixclient.VideoTypeUpload,
+			},
+		},
+		SecondUserName: {
+			{
+				ID:        "s1",
+				Title:     "Second Streamer Video 1",
+				CreatedAt: time.Date(2025, time.June, 2, 18, 0, 0, 0, time.UTC),
+				Duration:  "1h",
+				ViewCount: 600,
+				Type:      helixclient.VideoTypeArchive,
+			},
+			{
+				ID:        "s2",
+				Title:     "Second Streamer Video 2",
+				CreatedAt: time.Date(2025, time.June, 1, 18, 0, 0, 0, time.UTC),
+				Duration:  "1h",
+				ViewCount: 300,
+				Type:      helixclient.VideoTypeArchive,
+			},
+		},
+		NoVideosUserName: {},
+	}
+
+	// stubClips maps the broadcaster IDs known to the stub helix /clips endpoint to their clips, most
+	// viewed first as helix returns them
+	stubClips = map[string][]helixclient.ClipInfo{
+		"good_user": {
+			stubClip("c1", "Clip 1", "clipper_a", "v1", 500, time.Date(2025, time.June, 3, 13, 0, 0, 0, time.UTC)),
+			stubClip("c2", "Clip 2", "clipper_b", "v1", 300, time.Date(2025, time.June, 3, 14, 0, 0, 0, time.UTC)),
+			stubClip("c3", "Clip 3", "clipper_a", "", 200, time.Date(2025, time.June, 2, 13, 0, 0, 0, time.UTC)),
+			stubClip("c4", "Clip 4", "clipper_c", "v2", 50, time.Date(2025, time.June, 1, 13, 0, 0, 0, time.UTC)),
+		},
+		SecondUserName:   {},
+		NoVideosUserName: {},
+	}
+
+	// stubStreams maps the user IDs of the live streamers known to the stub helix /streams endpoint to
+	// their stream, every bulk user is live as well
+	stubStreams = map[string]helixclient.StreamInfo{
+		"good_user": {
+			ID:          "stream1",
+			UserID:      "good_user",
+			UserLogin:   "good_user",
+			UserName:    "Streamer A",
+			GameID:      "509658",
+			GameName:    "Just Chatting",
+			Type:        "live",
+			Title:       "Sample Stream",
+			ViewerCount: 1234,
+			StartedAt:   LiveStreamStartedAt,
+		},
+	}
+
+	// LiveStreamStartedAt is when the stub stream of good_user started
+	LiveStreamStartedAt = time.Date(2025, time.June, 4, 12, 0, 0, 0, time.UTC)
+
+	// PaginatedVideosNewest is the creation time of the newest paginated video, each
+	// following video was created one hour earlier than the one before it
+	PaginatedVideosNewest = time.Date(2025, time.June, 30, 12, 0, 0, 0, time.UTC)
+)
+
 func StubServerMux() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc(helixclient.HelixUsersEndpoint, mockGetHelixUserData)
 	mux.HandleFunc(helixclient.HelixVideosEndpoint, mockGetHelixVideosData)
+	mux.HandleFunc(helixclient.HelixClipsEndpoint, mockGetHelixClipsData)
+	mux.HandleFunc(helixclient.HelixStreamsEndpoint, mockGetHelixStreamsData)
+	mux.HandleFunc(helixclient.HelixTokenEndpoint, mockGetHelixAccessToken)
 	return mux
 }
 
-func mockGetHelixUserData(w http.ResponseWriter, r *http.Request) {
+func stubUser(id, login, displayName string) helixclient.UserInfo {
+	return helixclient.UserInfo{
+		ID:              id,
+		Login:           login,
+		DisplayName:     displayName,
+		ProfileImageURL: "https://example.com/streamerA.png",
+	}
+}
 
-	userName := r.URL.Query().Get("login")
+func mockGetHelixAccessToken(w http.ResponseWriter, r *http.Request) {
 
-	var mockResponse helixclient.UsersResponseBody
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-	switch userName {
-	case "":
-		http.Error(w, "no login provided", http.StatusBadRequest)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
 		return
-	case "good_user":
-		mockResponse = helixclient.UsersResponseBody{
-			Data: []struct {
-				ID              string `json:"id"`
-				Login           string `json:"login"`
-				DisplayName     string `json:"display_name"`
-				ProfileImageURL string `json:"profile_image_url"`
-			}{
-				{
-					ID:              "good_user",
-					Login:           userName,
-					DisplayName:     "Streamer A",
-					ProfileImageURL: "https://example.com/streamerA.png",
-				},
-			},
-		}
-	case "bad_user":
-		http.Error(w, "bad user", http.StatusBadRequest)
+	}
+
+	if r.PostForm.Get("client_id") != StubClientID ||
+		r.PostForm.Get("client_secret") != StubClientSecret ||
+		r.PostForm.Get("grant_type") != "client_credentials" {
+		http.Error(w, "invalid client", http.StatusForbidden)
 		return
-	case "no_data_user":
-		// use the default var
-	case "extra_data_user":
-		mockResponse = helixclient.UsersResponseBody{
-			Data: []struct {
-				ID              string `json:"id"`
-				Login           string `json:"login"`
-				DisplayName     string `json:"display_name"`
-				ProfileImageURL string `json:"profile_image_url"`
-			}{
-				{
-					ID:              "good_user",
-					Login:           userName,
-					DisplayName:     "Streamer A",
-					ProfileImageURL: "https://example.com/streamerA.png",
-				},
-				{
-					ID:              "good_user",
-					Login:           userName,
-					DisplayName:     "Streamer A",
-					ProfileImageURL: "https://example.com/streamerA.png",
-				},
-			},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(helixclient.TokenResponse{
+		AccessToken: StubAccessToken,
+		ExpiresIn:   3600,
+		TokenType:   "bearer",
+	})
+}
+
+func mockGetHelixUserData(w http.ResponseWriter, r *http.Request) {
+
+	logins := r.URL.Query()["login"]
+	userIDs := r.URL.Query()["id"]
+	if len(logins)+len(userIDs) == 0 || len(logins)+len(userIDs) > 100 {
+		http.Error(w, "between 1 and 100 logins and ids must be provided", http.StatusBadRequest)
+		return
+	}
+
+	mockResponse := helixclient.UsersResponseBody{
+		Data: []helixclient.UserInfo{},
+	}
+
+	for _, userName := range logins {
+
+		switch userName {
+		case "":
+			http.Error(w, "no login provided", http.StatusBadRequest)
+			return
+		case "bad_user":
+			http.Error(w, "bad user", http.StatusBadRequest)
+			return
+		case AuthorisedUserName:
+			if r.Header.Get("Authorization") != "Bearer "+StubAccessToken {
+				http.Error(w, "invalid oauth token", http.StatusUnauthorized)
+				return
+			}
+		case "extra_data_user":
+			// helix is not expected to return duplicates for one login, but the handlers must cope
+			mockResponse.Data = append(mockResponse.Data, stubUser("good_user", userName, "Streamer A"))
 		}
-	case "good_user_bad_video_request":
-		mockResponse = helixclient.UsersResponseBody{
-			Data: []struct {
-				ID              string `json:"id"`
-				Login           string `json:"login"`
-				DisplayName     string `json:"display_name"`
-				ProfileImageURL string `json:"profile_image_url"`
-			}{
-				{
-					ID:              "00000",
-					Login:           userName,
-					DisplayName:     "Streamer A",
-					ProfileImageURL: "https://example.com/streamerA.png",
-				},
-			},
+
+		if user, ok := stubUsers[userName]; ok {
+			mockResponse.Data = append(mockResponse.Data, user)
+		}
+
+		if suffix, ok := strings.CutPrefix(userName, BulkUserPrefix); ok {
+			mockResponse.Data = append(mockResponse.Data, stubUser(BulkUserIDPrefix+suffix, userName, userName))
+		}
+	}
+
+	for _, userID := range userIDs {
+
+		if login, ok := stubUserIDs[userID]; ok {
+			mockResponse.Data = append(mockResponse.Data, stubUsers[login])
+		}
+
+		if suffix, ok := strings.CutPrefix(userID, BulkUserIDPrefix); ok {
+			mockResponse.Data = append(mockResponse.Data, stubUser(userID, BulkUserPrefix+suffix, BulkUserPrefix+suffix))
 		}
 	}
 
@@ -91,35 +240,208 @@ func mockGetHelixUserData(w http.ResponseWriter, r *http.Request) {
 
 func mockGetHelixVideosData(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
-	if userID != "good_user" {
+	if userID == PaginatedUserID {
+		mockGetHelixPaginatedVideosData(w, r)
+		return
+	}
+
+	videos, ok := stubVideos[userID]
+	if !ok {
 		http.Error(w, "invalid or missing user_id", http.StatusBadRequest)
 		return
 	}
 
+	videoType := r.URL.Query().Get("type")
+	if videoType != "" && !slices.Contains(helixclient.VideoTypes, videoType) {
+		http.Error(w, "invalid type", http.StatusBadRequest)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 
 	resp := helixclient.VideosResponseBody{
-		Data: []helixclient.VideoInfo{
-			{
-				ID:        "v1",
-				Title:     "Sample Video 1",
-				Duration:  "30m",
-				ViewCount: 150,
-			},
-			{
-				ID:        "v2",
-				Title:     "Sample Video 2",
-				Duration:  "20m",
-				ViewCount: 100,
-			},
-			{
-				ID:        "v3",
-				Title:     "Sample Video 3",
-				Duration:  "10m",
-				ViewCount: 50,
-			},
-		},
+		Data: []helixclient.VideoInfo{},
+	}
+
+	for _, video := range videos {
+		if videoType == "" || videoType == helixclient.VideoTypeAll || video.Type == videoType {
+			resp.Data = append(resp.Data, video)
+		}
+	}
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func mockGetHelixPaginatedVideosData(w http.ResponseWriter, r *http.Request) {
+
+	first, err := strconv.Atoi(r.URL.Query().Get("first"))
+	if err != nil || first < 1 || first > 100 {
+		http.Error(w, "first must be between 1 and 100", http.StatusBadRequest)
+		return
+	}
+
+	offset := 0
+	if after := r.URL.Query().Get("after"); after != "" {
+		offset, err = strconv.Atoi(after)
+		if err != nil {
+			http.Error(w, "invalid after cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	end := min(offset+first, PaginatedVideoCount)
+
+	resp := helixclient.VideosResponseBody{
+		Data:       []helixclient.VideoInfo{},
+		Pagination: map[string]string{},
+	}
+
+	for i := offset; i < end; i++ {
+		resp.Data = append(resp.Data, helixclient.VideoInfo{
+			ID:        fmt.Sprintf("v%d", i),
+			Title:     fmt.Sprintf("Paginated Video %d", i),
+			CreatedAt: PaginatedVideosNewest.Add(-time.Duration(i) * time.Hour),
+			Duration:  "1m",
+			ViewCount: i,
+		})
+	}
+
+	if end < PaginatedVideoCount {
+		resp.Pagination["cursor"] = strconv.Itoa(end)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func stubClip(id, title, creator, videoID string, viewCount int, createdAt time.Time) helixclient.ClipInfo {
+	return helixclient.ClipInfo{
+		ID:          id,
+		URL:         "https://clips.twitch.tv/" + id,
+		CreatorID:   creator,
+		CreatorName: creator,
+		VideoID:     videoID,
+		Title:       title,
+		ViewCount:   viewCount,
+		CreatedAt:   createdAt,
+	}
+}
+
+// paginatedClips returns the clips of PaginatedUserID. Clip i has PaginatedClipCount-i views, was
+// created i hours before PaginatedVideosNewest, and was made from paginated video i.
+func paginatedClips() []helixclient.ClipInfo {
+
+	clips := make([]helixclient.ClipInfo, 0, PaginatedClipCount)
+	for i := range PaginatedClipCount {
+		clips = append(clips, stubClip(
+			fmt.Sprintf("c%d", i),
+			fmt.Sprintf("Paginated Clip %d", i),
+			fmt.Sprintf("clipper_%d", i%10),
+			fmt.Sprintf("v%d", i),
+			PaginatedClipCount-i,
+			PaginatedVideosNewest.Add(-time.Duration(i)*time.Hour),
+		))
 	}
 
+	return clips
+}
+
+func mockGetHelixClipsData(w http.ResponseWriter, r *http.Request) {
+
+	broadcasterID := r.URL.Query().Get("broadcaster_id")
+
+	clips, ok := stubClips[broadcasterID]
+	if broadcasterID == PaginatedUserID {
+		clips, ok = paginatedClips(), true
+	}
+	if !ok {
+		http.Error(w, "invalid or missing broadcaster_id", http.StatusBadRequest)
+		return
+	}
+
+	first, err := strconv.Atoi(r.URL.Query().Get("first"))
+	if err != nil || first < 1 || first > 100 {
+		http.Error(w, "first must be between 1 and 100", http.StatusBadRequest)
+		return
+	}
+
+	var startedAt, endedAt time.Time
+	if value := r.URL.Query().Get("started_at"); value != "" {
+		if startedAt, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "invalid started_at", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := r.URL.Query().Get("ended_at"); value != "" {
+		if endedAt, err = time.Parse(time.RFC3339, value); err != nil || startedAt.IsZero() {
+			http.Error(w, "ended_at requires a valid started_at", http.StatusBadRequest)
+			return
+		}
+	}
+
+	window := helixclient.VideoWindow{Since: startedAt, Until: endedAt}
+	clips = slices.DeleteFunc(slices.Clone(clips), func(clip helixclient.ClipInfo) bool {
+		return !window.Contains(clip.CreatedAt)
+	})
+
+	offset := 0
+	if after := r.URL.Query().Get("after"); after != "" {
+		offset, err = strconv.Atoi(after)
+		if err != nil {
+			http.Error(w, "invalid after cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	offset = min(offset, len(clips))
+	end := min(offset+first, len(clips))
+
+	resp := helixclient.ClipsResponseBody{
+		Data:       clips[offset:end],
+		Pagination: map[string]string{},
+	}
+
+	if end < len(clips) {
+		resp.Pagination["cursor"] = strconv.Itoa(end)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func mockGetHelixStreamsData(w http.ResponseWriter, r *http.Request) {
+
+	userIDs := r.URL.Query()["user_id"]
+	if len(userIDs) == 0 || len(userIDs) > 100 {
+		http.Error(w, "between 1 and 100 user ids must be provided", http.StatusBadRequest)
+		return
+	}
+
+	resp := helixclient.StreamsResponseBody{
+		Data:       []helixclient.StreamInfo{},
+		Pagination: map[string]string{},
+	}
+
+	for _, userID := range userIDs {
+
+		if stream, ok := stubStreams[userID]; ok {
+			resp.Data = append(resp.Data, stream)
+		}
+
+		if suffix, ok := strings.CutPrefix(userID, BulkUserIDPrefix); ok {
+			resp.Data = append(resp.Data, helixclient.StreamInfo{
+				ID:        "stream-" + suffix,
+				UserID:    userID,
+				UserLogin: BulkUserPrefix + suffix,
+				Type:      "live",
+				StartedAt: LiveStreamStartedAt,
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
 	_ = json.NewEncoder(w).Encode(resp)
 }
@@ -9,22 +9,80 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 	"ttv-statistics/api"
+	"ttv-statistics/collector"
 	"ttv-statistics/helixclient"
+	"ttv-statistics/storage"
 )
 
 const (
-	hostFlagName         string = "host"
-	hostHelpText         string = "the host address where the API should be hosted"
-	clientIDFlagName     string = "client-id"
-	clientSecretFlagName string = "client-secret"
-	clientIDHelpText     string = "the client ID used to access Twitch helix API"
-	clientSecretHelpText string = "the client secret used to access Twitch helix API"
-	helixHostFlagName    string = "helix-host"
-	helixHostHelpText    string = "the host address of the twitch helix API "
+	hostFlagName                  string = "host"
+	hostHelpText                  string = "the host address where the API should be hosted"
+	clientIDFlagName              string = "client-id"
+	clientSecretFlagName          string = "client-secret"
+	clientIDHelpText              string = "the client ID used to access Twitch helix API"
+	clientSecretHelpText          string = "the client secret used to access Twitch helix API"
+	helixHostFlagName             string = "helix-host"
+	helixHostHelpText             string = "the host address of the twitch helix API "
+	authHostFlagName              string = "auth-host"
+	authHostHelpText              string = "the host address of the twitch OAuth server used to obtain access tokens"
+	userCacheTTLFlagName          string = "user-cache-ttl"
+	userCacheTTLHelpText          string = "how long helix user lookups are cached, 0 disables the user cache"
+	videoCacheTTLFlagName         string = "video-cache-ttl"
+	videoCacheTTLHelpText         string = "how long helix video lists are cached, 0 disables the video cache"
+	clipCacheTTLFlagName          string = "clip-cache-ttl"
+	clipCacheTTLHelpText          string = "how long helix clip lists are cached, 0 disables the clip cache"
+	cacheMaxEntriesFlagName       string = "cache-max-entries"
+	cacheMaxEntriesHelpText       string = "the maximum number of entries held by each of the user, video and clip caches"
+	retryMaxAttemptsFlagName      string = "retry-max-attempts"
+	retryMaxAttemptsHelpText      string = "the total number of attempts made for a helix request failing transiently, 1 disables retries"
+	retryBaseDelayFlagName        string = "retry-base-delay"
+	retryBaseDelayHelpText        string = "the delay before the first retry of a helix request, doubling for each retry after it"
+	breakerFailurePercentFlagName string = "circuit-breaker-failure-percent"
+	breakerFailurePercentHelpText string = "the percentage of recent helix requests failing that opens the circuit breaker, 0 disables it"
+	breakerOpenDurationFlagName   string = "circuit-breaker-open-duration"
+	breakerOpenDurationHelpText   string = "how long the open circuit breaker fails fast before probing helix again"
+	defaultStorePath              string = "ttv-statistics.db"
+	storeFlagName                 string = "store"
+	storeHelpText                 string = "where streamers, videos and snapshots are stored, memory or file"
+	storePathFlagName             string = "store-path"
+	storePathHelpText             string = "the file holding the store when --store is file"
+	watchlistFlagName             string = "watchlist"
+	watchlistHelpText             string = "a comma separated list of streamer logins to snapshot periodically, empty disables the snapshot collector"
+	snapshotIntervalFlagName      string = "snapshot-interval"
+	snapshotIntervalHelpText      string = "how often the statistics of the watchlist are snapshotted, randomised by up to 10 percent"
+	snapshotVideosFlagName        string = "snapshot-videos"
+	snapshotVideosHelpText        string = "the number of most recent videos each snapshot is aggregated over"
+	snapshotConcurrencyFlagName   string = "snapshot-concurrency"
+	snapshotConcurrencyHelpText   string = "the maximum number of watchlist streamers snapshotted at once"
 )
 
 var (
+	clientID     string
+	clientSecret string
+	helixHost    string
+	authHost     string
+
+	userCacheTTL    time.Duration
+	videoCacheTTL   time.Duration
+	clipCacheTTL    time.Duration
+	cacheMaxEntries int
+
+	retryMaxAttempts int
+	retryBaseDelay   time.Duration
+
+	breakerFailurePercent int
+	breakerOpenDuration   time.Duration
+
+	store     string
+	storePath string
+
+	watchlist           string
+	snapshotInterval    time.Duration
+	snapshotVideos      int
+	snapshotConcurrency int
+
 	stringFlags = []stringFlag{
 		{
 			ptr:          &api.Host,
@@ -33,23 +91,113 @@ var (
 			helpText:     hostHelpText,
 		},
 		{
-			ptr:          &helixclient.ClientID,
+			ptr:          &clientID,
 			flagName:     clientIDFlagName,
 			defaultValue: "",
 			helpText:     clientIDHelpText,
 		},
 		{
-			ptr:          &helixclient.ClientSecret,
+			ptr:          &clientSecret,
 			flagName:     clientSecretFlagName,
 			defaultValue: "",
 			helpText:     clientSecretHelpText,
 		},
 		{
-			ptr:          &helixclient.HelixHost,
+			ptr:          &helixHost,
 			flagName:     helixHostFlagName,
 			defaultValue: "",
 			helpText:     helixHostHelpText,
 		},
+		{
+			ptr:          &authHost,
+			flagName:     authHostFlagName,
+			defaultValue: helixclient.DefaultHelixAuthHost,
+			helpText:     authHostHelpText,
+		},
+		{
+			ptr:          &store,
+			flagName:     storeFlagName,
+			defaultValue: storage.StoreMemory,
+			helpText:     storeHelpText,
+		},
+		{
+			ptr:          &storePath,
+			flagName:     storePathFlagName,
+			defaultValue: defaultStorePath,
+			helpText:     storePathHelpText,
+		},
+	}
+
+	durationFlags = []durationFlag{
+		{
+			ptr:          &userCacheTTL,
+			flagName:     userCacheTTLFlagName,
+			defaultValue: helixclient.DefaultUserCacheTTL,
+			helpText:     userCacheTTLHelpText,
+		},
+		{
+			ptr:          &videoCacheTTL,
+			flagName:     videoCacheTTLFlagName,
+			defaultValue: helixclient.DefaultVideoCacheTTL,
+			helpText:     videoCacheTTLHelpText,
+		},
+		{
+			ptr:          &clipCacheTTL,
+			flagName:     clipCacheTTLFlagName,
+			defaultValue: helixclient.DefaultClipCacheTTL,
+			helpText:     clipCacheTTLHelpText,
+		},
+		{
+			ptr:          &retryBaseDelay,
+			flagName:     retryBaseDelayFlagName,
+			defaultValue: helixclient.DefaultRetryBaseDelay,
+			helpText:     retryBaseDelayHelpText,
+		},
+		{
+			ptr:          &breakerOpenDuration,
+			flagName:     breakerOpenDurationFlagName,
+			defaultValue: helixclient.DefaultCircuitBreakerOpenDuration,
+			helpText:     breakerOpenDurationHelpText,
+		},
+		{
+			ptr:          &snapshotInterval,
+			flagName:     snapshotIntervalFlagName,
+			defaultValue: collector.DefaultInterval,
+			helpText:     snapshotIntervalHelpText,
+		},
+	}
+
+	intFlags = []intFlag{
+		{
+			ptr:          &cacheMaxEntries,
+			flagName:     cacheMaxEntriesFlagName,
+			defaultValue: helixclient.DefaultCacheMaxEntries,
+			helpText:     cacheMaxEntriesHelpText,
+		},
+		{
+			ptr:          &retryMaxAttempts,
+			flagName:     retryMaxAttemptsFlagName,
+			defaultValue: helixclient.DefaultRetryMaxAttempts,
+			helpText:     retryMaxAttemptsHelpText,
+		},
+		{
+			ptr:          &breakerFailurePercent,
+			flagName:     breakerFailurePercentFlagName,
+			defaultValue: int(helixclient.DefaultCircuitBreakerFailureRate * 100),
+			helpText:     breakerFailurePercentHelpText,
+		},
+		{
+			ptr:          &snapshotVideos,
+			flagName:     snapshotVideosFlagName,
+			defaultValue: collector.DefaultVideoCount,
+			helpText:     snapshotVideosHelpText,
+		},
+		{
+			ptr:          &snapshotConcurrency,
+			flagName:     snapshotConcurrencyFlagName,
+			defaultValue: collector.DefaultConcurrency,
+			helpText:     snapshotConcurrencyHelpText,
+		},
 	}
 )
 
@@ -60,12 +208,37 @@ type stringFlag struct {
 	helpText     string
 }
 
+type durationFlag struct {
+	ptr          *time.Duration
+	flagName     string
+	defaultValue time.Duration
+	helpText     string
+}
+
+type intFlag struct {
+	ptr          *int
+	flagName     string
+	defaultValue int
+	helpText     string
+}
+
 func parseFlags() error {
 
 	for _, stringFlag := range stringFlags {
 		flag.StringVar(stringFlag.ptr, stringFlag.flagName, stringFlag.defaultValue, stringFlag.helpText)
 	}
 
+	// the watchlist is optional, so it is not validated with the required string flags
+	flag.StringVar(&watchlist, watchlistFlagName, "", watchlistHelpText)
+
+	for _, durationFlag := range durationFlags {
+		flag.DurationVar(durationFlag.ptr, durationFlag.flagName, durationFlag.defaultValue, durationFlag.helpText)
+	}
+
+	for _, intFlag := range intFlags {
+		flag.IntVar(intFlag.ptr, intFlag.flagName, intFlag.defaultValue, intFlag.helpText)
+	}
+
 	flag.Parse()
 
 	missingFlags := []string{}
@@ -84,17 +257,64 @@ func parseFlags() error {
 
 }
 
-func runServerAndAwaitShutdown() error {
+func runServerAndAwaitShutdown(helix *helixclient.Client, repository storage.Repository) error {
 
-	server := api.NewTTVStatisticsServer()
+	cachedHelix := helixclient.NewCachedClient(
+		helix,
+		helixclient.WithUserCacheTTL(userCacheTTL),
+		helixclient.WithVideoCacheTTL(videoCacheTTL),
+		helixclient.WithClipCacheTTL(clipCacheTTL),
+		helixclient.WithCacheMaxEntries(cacheMaxEntries),
+	)
+
+	server := api.NewTTVStatisticsServer(cachedHelix, repository, helix.HelixHost(), helix.AuthHost())
 	server.Run()
+
+	// snapshots bypass the cache, so each one reflects helix at the time it was taken
+	var snapshotCollector *collector.Collector
+	if logins := parseWatchlist(watchlist); len(logins) > 0 {
+		snapshotCollector = collector.NewCollector(
+			helix,
+			repository,
+			logins,
+			collector.WithInterval(snapshotInterval),
+			collector.WithVideoCount(snapshotVideos),
+			collector.WithConcurrency(snapshotConcurrency),
+		)
+		snapshotCollector.Start(context.Background())
+		log.Printf("snapshotting %d streamers every %s", len(logins), snapshotInterval)
+	}
+
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
 	<-signals
+
+	if snapshotCollector != nil {
+		if err := snapshotCollector.Stop(context.Background()); err != nil {
+			log.Printf("failed to stop snapshot collector: %v", err)
+		}
+	}
+
+	stats := cachedHelix.Stats()
+	log.Printf("helix cache stats: user_hits=%d user_misses=%d video_hits=%d video_misses=%d clip_hits=%d clip_misses=%d",
+		stats.UserHits, stats.UserMisses, stats.VideoHits, stats.VideoMisses, stats.ClipHits, stats.ClipMisses)
+
 	return server.ShutDownServer(context.Background())
 
 }
 
+func parseWatchlist(watchlist string) []string {
+
+	logins := []string{}
+	for login := range strings.SplitSeq(watchlist, ",") {
+		if login = strings.TrimSpace(login); login != "" {
+			logins = append(logins, login)
+		}
+	}
+
+	return logins
+}
+
 func main() {
 
 	parseFlagsError := parseFlags()
@@ -105,12 +325,39 @@ func main() {
 		os.Exit(1)
 	}
 
-	clientAuthError := helixclient.InitHelixClientAuth(context.Background())
+	retryPolicy := helixclient.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = retryMaxAttempts
+	retryPolicy.BaseDelay = retryBaseDelay
+
+	circuitBreakerPolicy := helixclient.DefaultCircuitBreakerPolicy()
+	circuitBreakerPolicy.FailureRate = float64(breakerFailurePercent) / 100
+	circuitBreakerPolicy.OpenDuration = breakerOpenDuration
+
+	helix := helixclient.NewClient(
+		helixclient.WithHelixHost(helixHost),
+		helixclient.WithAuthHost(authHost),
+		helixclient.WithCredentials(clientID, clientSecret),
+		helixclient.WithRetryPolicy(retryPolicy),
+		helixclient.WithCircuitBreakerPolicy(circuitBreakerPolicy),
+	)
+
+	clientAuthError := helix.Authenticate(context.Background())
 	if clientAuthError != nil {
-		log.Printf("Failed to authenticate with TwithTV API. Error: %v", clientAuthError)
+		log.Printf("Failed to authenticate with TwithTV API, authentication will be retried on the next request. Error: %v", clientAuthError)
+	}
+
+	repository, storeError := storage.Open(store, storePath)
+	if storeError != nil {
+		log.Printf("Failed to open %s store. Error: %v", store, storeError)
+		os.Exit(1)
+	}
+
+	serverShutdownError := runServerAndAwaitShutdown(helix, repository)
+
+	if storeCloseError := repository.Close(); storeCloseError != nil {
+		log.Printf("Failed to close %s store. Error: %v", store, storeCloseError)
 	}
 
-	serverShutdownError := runServerAndAwaitShutdown()
 	if serverShutdownError != nil {
 		log.Printf("Server failed to shutdown gracefully. Error: %v", serverShutdownError)
 		os.Exit(1)